@@ -0,0 +1,285 @@
+package state
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+type (
+	addCommand    State
+	removeCommand State
+)
+
+func (c *addCommand) GetName() string { return "add" }
+
+func (c *addCommand) Flags(fs *flag.FlagSet) {
+	(*State)(c).writeFlags(fs)
+	fs.StringVar(&c.after, "after", "", "the block after which to insert the templates (default sorted by name)")
+}
+
+// Run appends managed blocks for the named templates to the local gitignore file, sorted among the existing blocks
+// by name or after the block named by -after, and updates any lockfile.
+func (c *addCommand) Run() ExitStatus {
+	s := (*State)(c)
+	logger := s.Logger()
+
+	if len(s.templates) == 0 {
+		logger.Error("add requires at least one template")
+		return 2
+	}
+
+	if err := s.Add(s.templates...); err != nil {
+		logger.Error(err.Error())
+		return 1
+	}
+
+	return 0
+}
+
+// Add inserts managed blocks for the named templates into the local gitignore file.
+func (s *State) Add(names ...string) error {
+	old, err := s.ReadGitignore(GitignoreFile)
+	if err != nil {
+		return err
+	}
+
+	cat, err := s.Catalog()
+	if err != nil {
+		return err
+	}
+
+	templates, err := cat.Resolve(names...)
+	if err != nil {
+		return err
+	}
+
+	sections, err := s.Sections(cat, templates)
+	if err != nil {
+		return err
+	}
+
+	g, err := AddBlocks(old, sections, s.after)
+	if err != nil {
+		return fmt.Errorf("%s: %v", GitignoreFile, err)
+	}
+
+	u := &Update{Name: GitignoreFile, Old: old, New: g, Catalog: cat}
+	for _, sec := range sections {
+		u.Added = append(u.Added, sec.Template.Name)
+	}
+
+	if err := s.findNewlyIgnored(u); err != nil {
+		return err
+	}
+
+	s.reportUpdate(u)
+
+	if err := s.WriteGitignore(u.Name, u.New); err != nil {
+		return err
+	}
+
+	return s.updateLock(u)
+}
+
+// AddBlocks inserts a managed block for each section. Blocks are placed after the block named after, or if after is
+// empty, before the first existing block whose name sorts after theirs. Lines outside of blocks are left unchanged.
+// Returns an error if a block for a section already exists.
+func AddBlocks(g *Gitignore, sections []*Section, after string) (*Gitignore, error) {
+	for _, sec := range sections {
+		blocks, err := g.Blocks()
+		if err != nil {
+			return nil, err
+		}
+
+		if findBlock(blocks, sec.Template.Name) != nil {
+			return nil, fmt.Errorf("block %s already exists; use update to refresh it", sec.Template.Name)
+		}
+
+		var (
+			lines = g.texts()
+			block = sec.Lines()
+			at    int
+		)
+
+		if after != "" {
+			prev := findBlock(blocks, after)
+			if prev == nil {
+				return nil, fmt.Errorf("no managed block named %s", after)
+			}
+			at = prev.End + 1
+			block = append([]string{""}, block...)
+			// the next section is inserted after this one
+			after = sec.Template.Name
+		} else {
+			at, block = sortedPosition(lines, blocks, sec.Template.Name, block)
+		}
+
+		lines = append(lines[:at:at], append(block, lines[at:]...)...)
+		g = ParseGitignoreString(strings.Join(lines, "\n") + "\n")
+	}
+
+	return g, nil
+}
+
+// sortedPosition returns where to insert the block so managed blocks stay sorted by name, and the block lines
+// padded with the blank line separating it from its neighbours.
+func sortedPosition(lines []string, blocks []*Block, name string, block []string) (int, []string) {
+	for _, b := range blocks {
+		if strings.ToLower(b.Name) > strings.ToLower(name) {
+			return b.Begin, append(block, "")
+		}
+	}
+
+	if len(blocks) > 0 {
+		return blocks[len(blocks)-1].End + 1, append([]string{""}, block...)
+	}
+
+	// without any blocks, managed content goes above the unmanaged rules
+	if len(lines) > 0 {
+		block = append(block, "")
+	}
+	return 0, block
+}
+
+func (c *removeCommand) GetName() string { return "remove" }
+
+func (c *removeCommand) Flags(fs *flag.FlagSet) {
+	(*State)(c).writeFlags(fs)
+}
+
+// Run deletes the named managed blocks from the local gitignore file and updates any lockfile.
+func (c *removeCommand) Run() ExitStatus {
+	s := (*State)(c)
+	logger := s.Logger()
+
+	if len(s.templates) == 0 {
+		logger.Error("remove requires at least one template")
+		return 2
+	}
+
+	if err := s.Remove(s.templates...); err != nil {
+		logger.Error(err.Error())
+		return 1
+	}
+
+	return 0
+}
+
+// Remove deletes the named managed blocks from the local gitignore file.
+func (s *State) Remove(names ...string) error {
+	g, err := s.ReadGitignore(GitignoreFile)
+	if err != nil {
+		return err
+	}
+
+	g, removed, err := RemoveBlocks(g, names...)
+	if err != nil {
+		return fmt.Errorf("%s: %v", GitignoreFile, err)
+	}
+
+	for _, name := range removed {
+		s.Logger().Infof("removing %s", name)
+	}
+
+	if err := s.WriteGitignore(GitignoreFile, g); err != nil {
+		return err
+	}
+
+	lock, err := s.ReadLock()
+	if err != nil || lock == nil {
+		return err
+	}
+
+	kept := lock.Templates[:0]
+	for _, t := range lock.Templates {
+		if !contains(removed, t.Name) {
+			kept = append(kept, t)
+		}
+	}
+	lock.Templates = kept
+
+	return s.WriteLock(lock)
+}
+
+// RemoveBlocks deletes the named managed blocks and the blank line separating each from its neighbours. Names are
+// compared case-insensitively if there is no exact match. Returns the names of the removed blocks, or an error if
+// any name has no block.
+func RemoveBlocks(g *Gitignore, names ...string) (*Gitignore, []string, error) {
+	blocks, err := g.Blocks()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		drop    = make(map[int]bool)
+		removed []string
+	)
+
+	for _, name := range names {
+		b := findBlock(blocks, name)
+		if b == nil {
+			return nil, nil, fmt.Errorf("no managed block named %s", name)
+		}
+		if contains(removed, b.Name) {
+			continue
+		}
+		removed = append(removed, b.Name)
+
+		for idx := b.Begin; idx <= b.End; idx++ {
+			drop[idx] = true
+		}
+
+		switch {
+		case b.End+1 < len(g.Lines) && g.Lines[b.End+1].Kind == BlankLine:
+			drop[b.End+1] = true
+		case b.Begin > 0 && g.Lines[b.Begin-1].Kind == BlankLine:
+			drop[b.Begin-1] = true
+		}
+	}
+
+	var lines []string
+	for idx, text := range g.texts() {
+		if !drop[idx] {
+			lines = append(lines, text)
+		}
+	}
+
+	if len(lines) == 0 {
+		return new(Gitignore), removed, nil
+	}
+
+	return ParseGitignoreString(strings.Join(lines, "\n") + "\n"), removed, nil
+}
+
+// findBlock returns the block with the name, compared case-insensitively if there is no exact match, or nil.
+func findBlock(blocks []*Block, name string) *Block {
+	var fold *Block
+	for _, b := range blocks {
+		if b.Name == name {
+			return b
+		}
+		if fold == nil && strings.EqualFold(b.Name, name) {
+			fold = b
+		}
+	}
+	return fold
+}
+
+// texts returns the text of each line.
+func (g *Gitignore) texts() []string {
+	rv := make([]string, len(g.Lines))
+	for idx, line := range g.Lines {
+		rv[idx] = line.Text
+	}
+	return rv
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
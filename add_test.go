@@ -0,0 +1,227 @@
+package state
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func block(name string, lines ...string) string {
+	return chain(
+		"# BEGIN update-gitignore: "+name+" repo=github/gitignore path="+name+".gitignore\n",
+		chain(lines...),
+		"# END update-gitignore: "+name+"\n",
+	)
+}
+
+func TestAddBlocks(t *testing.T) {
+	cases := []struct {
+		name     string
+		text     string
+		sections []string
+		after    string
+		output   string
+		err      *string
+	}{
+		{
+			"empty",
+			"",
+			[]string{"Go"},
+			"",
+			block("Go", "go\n"),
+			nil,
+		},
+		{
+			"above local rules",
+			"# local\n*.log\n",
+			[]string{"Go"},
+			"",
+			chain(block("Go", "go\n"), "\n", "# local\n*.log\n"),
+			nil,
+		},
+		{
+			"sorted",
+			chain(block("C", "c\n"), "\n", block("Node", "node\n"), "\n", "*.log\n"),
+			[]string{"Go", "Rust", "Ada"},
+			"",
+			chain(
+				block("Ada", "ada\n"), "\n",
+				block("C", "c\n"), "\n",
+				block("Go", "go\n"), "\n",
+				block("Node", "node\n"), "\n",
+				block("Rust", "rust\n"), "\n",
+				"*.log\n",
+			),
+			nil,
+		},
+		{
+			"after",
+			chain(block("C", "c\n"), "\n", block("Node", "node\n")),
+			[]string{"Rust", "Ada"},
+			"c",
+			chain(
+				block("C", "c\n"), "\n",
+				block("Rust", "rust\n"), "\n",
+				block("Ada", "ada\n"), "\n",
+				block("Node", "node\n"),
+			),
+			nil,
+		},
+		{
+			"after missing",
+			block("C", "c\n"),
+			[]string{"Go"},
+			"Node",
+			"",
+			strptr("no managed block named Node"),
+		},
+		{
+			"exists",
+			block("Go", "go\n"),
+			[]string{"Go"},
+			"",
+			"",
+			strptr("block Go already exists; use update to refresh it"),
+		},
+		{
+			"unbalanced",
+			"# BEGIN update-gitignore: Go\n",
+			[]string{"Node"},
+			"",
+			"",
+			strptr("line 1: block Go is not terminated"),
+		},
+	}
+
+	t.Parallel()
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			sections := make([]*Section, len(tt.sections))
+			for idx, name := range tt.sections {
+				sections[idx] = newSection(name, strings.ToLower(name)+"\n")
+			}
+
+			g, err := AddBlocks(ParseGitignoreString(tt.text), sections, tt.after)
+			if errEquals(t, tt.err, err) && tt.err == nil {
+				assert.Equal(t, tt.output, g.String())
+			}
+		})
+	}
+}
+
+func TestRemoveBlocks(t *testing.T) {
+	cases := []struct {
+		name    string
+		text    string
+		names   []string
+		output  string
+		removed []string
+		err     *string
+	}{
+		{
+			"middle",
+			chain(block("C", "c\n"), "\n", block("Go", "go\n"), "\n", block("Node", "node\n")),
+			[]string{"go"},
+			chain(block("C", "c\n"), "\n", block("Node", "node\n")),
+			[]string{"Go"},
+			nil,
+		},
+		{
+			"last",
+			chain("*.log\n", "\n", block("Go", "go\n")),
+			[]string{"Go", "Go"},
+			"*.log\n",
+			[]string{"Go"},
+			nil,
+		},
+		{
+			"only",
+			block("Go", "go\n"),
+			[]string{"Go"},
+			"",
+			[]string{"Go"},
+			nil,
+		},
+		{
+			"missing",
+			block("Go", "go\n"),
+			[]string{"Node"},
+			"",
+			nil,
+			strptr("no managed block named Node"),
+		},
+	}
+
+	t.Parallel()
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			g, removed, err := RemoveBlocks(ParseGitignoreString(tt.text), tt.names...)
+			if errEquals(t, tt.err, err) && tt.err == nil {
+				assert.Equal(t, tt.output, g.String())
+				assert.Equal(t, tt.removed, removed)
+			}
+		})
+	}
+}
+
+func TestAddRemoveCommand_Run(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	path := filepath.Join(dir, ".gitignore")
+	writeFile(t, path, "# local\n*.log\n")
+
+	var s *State
+	run := func(args ...string) (ExitStatus, string) {
+		s = newState(nil, "valid", append([]string{"-C", dir}, args...)...)
+		require.NoError(t, s.ParseArguments())
+
+		cmd, err := s.Command()
+		require.NoError(t, err)
+		assert.Equal(t, args[0], cmd.GetName())
+		status := cmd.Run()
+		require.NoError(t, s.Logger().ShutdownLoggers())
+		return status, s.Stderr.(*bytes.Buffer).String()
+	}
+
+	writeFile(t, filepath.Join(dir, LockFile), `{"version": 1, "repo": "github/gitignore", "templates": []}`)
+
+	status, _ := run("add", "Linux")
+	assert.Equal(t, ExitStatus(0), status)
+
+	buf, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	g := ParseGitignoreString(string(buf))
+	blocks, err := g.Blocks()
+	require.NoError(t, err)
+	require.Len(t, blocks, 1)
+	assert.Equal(t, "b56bf65d85583b03eeccfaa2a927084583a33e91", blocks[0].SHA)
+	assert.Equal(t, "# local", g.Lines[blocks[0].End+2].Text)
+
+	lock, err := s.ReadLock()
+	require.NoError(t, err)
+	require.Len(t, lock.Templates, 1)
+	assert.Equal(t, "Linux", lock.Templates[0].Name)
+
+	status, stderr := run("add", "Linux")
+	assert.Equal(t, ExitStatus(1), status)
+	assert.Contains(t, stderr, "block Linux already exists")
+
+	status, _ = run("remove", "Linux")
+	assert.Equal(t, ExitStatus(0), status)
+
+	buf, err = ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "# local\n*.log\n", string(buf))
+
+	lock, err = s.ReadLock()
+	require.NoError(t, err)
+	assert.Empty(t, lock.Templates)
+}
@@ -0,0 +1,212 @@
+package state
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// SeverityWarning marks diagnostics for rules that probably don't do what was intended.
+	SeverityWarning Severity = "warning"
+	// SeverityError marks diagnostics for rules that can never have an effect.
+	SeverityError Severity = "error"
+)
+
+type (
+	// Severity is the severity of a diagnostic.
+	Severity string
+
+	// Diagnostic is a problem found with a rule.
+	Diagnostic struct {
+		Source   string   `json:"source"`
+		Line     int      `json:"line"`
+		Severity Severity `json:"severity"`
+		Code     string   `json:"code"`
+		Message  string   `json:"message"`
+	}
+)
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s:%d: %s: %s", d.Source, d.Line, d.Severity, d.Message)
+}
+
+// HasErrors reports whether any of the diagnostics are errors.
+func HasErrors(diagnostics []Diagnostic) bool {
+	for _, d := range diagnostics {
+		if d.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// NewSectionMatcher returns a Matcher for the rules of the sections as if they were composed into one file.
+func NewSectionMatcher(sections []*Section) *Matcher {
+	m := new(Matcher)
+	for _, sec := range sections {
+		for _, line := range sec.Content.Patterns() {
+			m.AddRule(&Rule{Line: line, Source: sec.Template.Path, Block: sec.Template.Name})
+		}
+	}
+	return m
+}
+
+// AnalyzeConflicts looks for negations that can never take effect and for rules from one template that are
+// overridden by a later template. Rules are compared using a sample path generated from each pattern, so the
+// analysis finds likely conflicts rather than proving them.
+func AnalyzeConflicts(rules []*Rule) []Diagnostic {
+	var rv []Diagnostic
+
+	for i, r := range rules {
+		sample, ok := r.sample()
+		if !ok {
+			continue
+		}
+
+		if r.Pattern.Negated {
+			prior := &Matcher{rules: rules[:i]}
+			if parent := prior.ExcludedParent(sample); parent != nil {
+				rv = append(rv, r.diagnostic(
+					SeverityError,
+					"unreachable-negation",
+					"%s can never take effect because a parent directory is excluded by %s",
+					r.describe(), parent.describe(),
+				))
+				continue
+			}
+		}
+
+		for _, later := range rules[i+1:] {
+			if later.Block == r.Block || later.Pattern.Negated == r.Pattern.Negated {
+				continue
+			}
+
+			if r.Pattern.Negated {
+				only := &Matcher{rules: []*Rule{later}}
+				if only.Match(sample, r.Pattern.DirOnly) != nil {
+					rv = append(rv, r.diagnostic(
+						SeverityWarning,
+						"overridden-negation",
+						"%s has no effect on %s because it is ignored again by %s",
+						r.describe(), sample, later.describe(),
+					))
+					break
+				}
+			} else if other, ok := later.sample(); ok && r.Matches(other, later.Pattern.DirOnly) {
+				rv = append(rv, r.diagnostic(
+					SeverityWarning,
+					"overridden",
+					"%s is overridden for %s by %s",
+					r.describe(), other, later.describe(),
+				))
+			}
+		}
+	}
+
+	return rv
+}
+
+func (r *Rule) describe() string {
+	text := trimTrailingSpaces(r.Text)
+	if r.Block != "" {
+		return fmt.Sprintf("%q from %s", text, r.Block)
+	}
+	return fmt.Sprintf("%q (%s:%d)", text, r.Source, r.Number)
+}
+
+func (r *Rule) diagnostic(severity Severity, code, format string, args ...interface{}) Diagnostic {
+	return Diagnostic{
+		Source:   r.Source,
+		Line:     r.Number,
+		Severity: severity,
+		Code:     code,
+		Message:  fmt.Sprintf(format, args...),
+	}
+}
+
+// sample returns a path matched by the rule, and false if one couldn't be generated.
+func (r *Rule) sample() (string, bool) {
+	sample := r.Pattern.Sample()
+	if r.Base != "" {
+		sample = r.Base + "/" + sample
+	}
+	if sample == "" || !r.Matches(sample, r.Pattern.DirOnly) {
+		return "", false
+	}
+	return sample, true
+}
+
+// Sample returns an example path matched by the glob by substituting each wildcard with a simple value.
+func (p *Pattern) Sample() string {
+	return p.sample("x")
+}
+
+func (p *Pattern) sample(filler string) string {
+	var (
+		b    strings.Builder
+		glob = p.Glob
+	)
+
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch c {
+		case '\\':
+			if i+1 < len(glob) {
+				i++
+				b.WriteByte(glob[i])
+			}
+		case '?':
+			b.WriteByte(filler[0])
+		case '*':
+			if strings.HasPrefix(glob[i:], "**/") && (i == 0 || glob[i-1] == '/') {
+				// "**/" may match nothing
+				i += 2
+				continue
+			}
+			for i+1 < len(glob) && glob[i+1] == '*' {
+				i++
+			}
+			b.WriteString(filler)
+		case '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				b.WriteByte(c)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			switch {
+			case strings.HasPrefix(class, "!"), strings.HasPrefix(class, "^"):
+				b.WriteByte('_')
+			case strings.HasPrefix(class, "[:"):
+				b.WriteByte(posixSample(class))
+				// skip the closing bracket of the class name
+				end++
+			case class == "":
+				b.WriteByte(']')
+				end = strings.IndexByte(glob[i+2:], ']') + 1
+			default:
+				b.WriteByte(class[0])
+			}
+			i += end + 1
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	return b.String()
+}
+
+func posixSample(class string) byte {
+	switch {
+	case strings.Contains(class, "digit"):
+		return '0'
+	case strings.Contains(class, "upper"):
+		return 'A'
+	case strings.Contains(class, "space"), strings.Contains(class, "blank"):
+		return ' '
+	case strings.Contains(class, "punct"):
+		return '_'
+	default:
+		return 'a'
+	}
+}
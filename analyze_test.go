@@ -0,0 +1,111 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPattern_Sample(t *testing.T) {
+	cases := []struct {
+		pattern string
+		sample  string
+	}{
+		{"*.log", "x.log"},
+		{"**/logs", "logs"},
+		{"a/**/b", "a/b"},
+		{"abc/**", "abc/x"},
+		{"?.txt", "x.txt"},
+		{"[Dd]esktop.ini", "Desktop.ini"},
+		{"file[[:digit:]].txt", "file0.txt"},
+		{"*.[!oa]", "x._"},
+		{"\\#hash", "#hash"},
+		{"yarn.lock", "yarn.lock"},
+	}
+
+	t.Parallel()
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.pattern, func(t *testing.T) {
+			p := ParsePattern(tt.pattern)
+			sample := p.Sample()
+			assert.Equal(t, tt.sample, sample)
+			assert.True(t, (&Rule{Line: &Line{Pattern: p}}).Matches(sample, p.DirOnly))
+		})
+	}
+}
+
+func TestAnalyzeConflicts(t *testing.T) {
+	cases := []struct {
+		name        string
+		sections    []*Section
+		diagnostics []Diagnostic
+	}{
+		{
+			"clean",
+			[]*Section{
+				newSection("VisualStudioCode", ".vscode/*\n!.vscode/settings.json\n"),
+				newSection("Go", "*.exe\n"),
+			},
+			nil,
+		},
+		{
+			"ignore overridden by later negation",
+			[]*Section{
+				newSection("Go", "*.lock\n"),
+				newSection("Node", "!yarn.lock\n"),
+			},
+			[]Diagnostic{
+				{
+					Source:   "Go.gitignore",
+					Line:     1,
+					Severity: SeverityWarning,
+					Code:     "overridden",
+					Message:  `"*.lock" from Go is overridden for yarn.lock by "!yarn.lock" from Node`,
+				},
+			},
+		},
+		{
+			"negation overridden by later ignore",
+			[]*Section{
+				newSection("Node", "!yarn.lock\n"),
+				newSection("Go", "*.lock\n"),
+			},
+			[]Diagnostic{
+				{
+					Source:   "Node.gitignore",
+					Line:     1,
+					Severity: SeverityWarning,
+					Code:     "overridden-negation",
+					Message:  `"!yarn.lock" from Node has no effect on yarn.lock because it is ignored again by "*.lock" from Go`,
+				},
+			},
+		},
+		{
+			"unreachable negation",
+			[]*Section{
+				newSection("Build", "# output\nbuild/\n"),
+				newSection("Keep", "!build/keep.txt\n"),
+			},
+			[]Diagnostic{
+				{
+					Source:   "Keep.gitignore",
+					Line:     1,
+					Severity: SeverityError,
+					Code:     "unreachable-negation",
+					Message:  `"!build/keep.txt" from Keep can never take effect because a parent directory is excluded by "build/" from Build`,
+				},
+			},
+		},
+	}
+
+	t.Parallel()
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			diagnostics := AnalyzeConflicts(NewSectionMatcher(tt.sections).Rules())
+			assert.Equal(t, tt.diagnostics, diagnostics)
+			assert.Equal(t, tt.name == "unreachable negation", HasErrors(diagnostics))
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v24/github"
+)
+
+type (
+	authCommand State
+
+	// AuthStatus describes the credentials used to talk to GitHub.
+	AuthStatus struct {
+		Login       string               `json:"login"`
+		Anonymous   bool                 `json:"anonymous"`
+		TokenSource string               `json:"token_source,omitempty"`
+		Scopes      []string             `json:"scopes"`
+		RateLimits  map[string]RateLimit `json:"rate_limits"`
+	}
+
+	// RateLimit is a single rate limit category reported by GitHub.
+	RateLimit struct {
+		Limit     int       `json:"limit"`
+		Remaining int       `json:"remaining"`
+		Reset     time.Time `json:"reset"`
+	}
+)
+
+func (c *authCommand) GetName() string { return "auth" }
+
+func (c *authCommand) Run() ExitStatus {
+	s := (*State)(c)
+	logger := s.Logger()
+
+	if len(s.templates) > 1 || (len(s.templates) == 1 && s.templates[0] != "status") {
+		logger.Errorf("unrecognized auth action %s", strings.Join(s.templates, " "))
+		return 2
+	}
+
+	status, err := s.AuthStatus()
+	if err != nil {
+		logger.Error(err.Error())
+		return 1
+	}
+
+	if s.format == "json" {
+		enc := json.NewEncoder(s.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(status); err != nil {
+			logger.Error(err.Error())
+			return 1
+		}
+		return 0
+	}
+
+	scopes := "(none)"
+	if len(status.Scopes) > 0 {
+		scopes = strings.Join(status.Scopes, ", ")
+	}
+	source := status.TokenSource
+	if source == "" {
+		source = "(none)"
+	}
+
+	fmt.Fprintf(s.Stdout, "Login:        %s\n", status.Login)
+	fmt.Fprintf(s.Stdout, "Token source: %s\n", source)
+	fmt.Fprintf(s.Stdout, "Scopes:       %s\n", scopes)
+	for _, name := range []string{"core", "search"} {
+		if rl, ok := status.RateLimits[name]; ok {
+			fmt.Fprintf(
+				s.Stdout,
+				"%-13s %d/%d remaining, resets %s\n",
+				strings.Title(name)+":",
+				rl.Remaining,
+				rl.Limit,
+				rl.Reset.Format(time.RFC3339),
+			)
+		}
+	}
+
+	return 0
+}
+
+// AuthStatus queries GitHub for the identity and rate limits of the configured credentials.
+func (s *State) AuthStatus() (*AuthStatus, error) {
+	cl, err := s.Client()
+	if err != nil {
+		return nil, err
+	}
+
+	status := AuthStatus{
+		Login:      "anonymous",
+		Anonymous:  true,
+		Scopes:     []string{},
+		RateLimits: make(map[string]RateLimit),
+	}
+
+	if _, err := cl.Token(); err == nil {
+		user, scopes, err := cl.GetUserScopes()
+		if err != nil {
+			return nil, err
+		}
+
+		status.Login = user.GetLogin()
+		status.Anonymous = false
+		status.TokenSource = cl.AuthSource()
+		if scopes != nil {
+			status.Scopes = scopes
+		}
+	}
+
+	rl, err := cl.GetRateLimits()
+	if err != nil {
+		return nil, err
+	}
+
+	for name, rate := range map[string]*github.Rate{"core": rl.Core, "search": rl.Search} {
+		if rate != nil {
+			status.RateLimits[name] = RateLimit{
+				Limit:     rate.Limit,
+				Remaining: rate.Remaining,
+				Reset:     rate.Reset.Time.UTC(),
+			}
+		}
+	}
+
+	return &status, nil
+}
@@ -0,0 +1,95 @@
+package state
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthCommand_Run(t *testing.T) {
+	cases := []struct {
+		name        string
+		environment []string
+		arguments   []string
+		stdout      string
+		exitcode    ExitStatus
+	}{
+		{
+			"text",
+			[]string{"GITHUB_TOKEN=faketoken"},
+			[]string{"auth", "status"},
+			chain(
+				"Login:        demosdemon\n",
+				"Token source: environment\n",
+				"Scopes:       (none)\n",
+				"Core:         4984/5000 remaining, resets 2019-03-25T02:45:24Z\n",
+				"Search:       30/30 remaining, resets 2019-03-25T01:46:24Z\n",
+			),
+			0,
+		},
+		{
+			"anonymous",
+			[]string{"GIT_CONFIG_NOSYSTEM=1"},
+			[]string{"auth"},
+			chain(
+				"Login:        anonymous\n",
+				"Token source: (none)\n",
+				"Scopes:       (none)\n",
+				"Core:         4984/5000 remaining, resets 2019-03-25T02:45:24Z\n",
+				"Search:       30/30 remaining, resets 2019-03-25T01:46:24Z\n",
+			),
+			0,
+		},
+		{
+			"json",
+			[]string{"GITHUB_TOKEN=faketoken"},
+			[]string{"-format", "json", "auth"},
+			chain(
+				"{\n",
+				"  \"login\": \"demosdemon\",\n",
+				"  \"anonymous\": false,\n",
+				"  \"token_source\": \"environment\",\n",
+				"  \"scopes\": [],\n",
+				"  \"rate_limits\": {\n",
+				"    \"core\": {\n",
+				"      \"limit\": 5000,\n",
+				"      \"remaining\": 4984,\n",
+				"      \"reset\": \"2019-03-25T02:45:24Z\"\n",
+				"    },\n",
+				"    \"search\": {\n",
+				"      \"limit\": 30,\n",
+				"      \"remaining\": 30,\n",
+				"      \"reset\": \"2019-03-25T01:46:24Z\"\n",
+				"    }\n",
+				"  }\n",
+				"}\n",
+			),
+			0,
+		},
+		{
+			"invalid",
+			nil,
+			[]string{"auth", "login"},
+			"",
+			2,
+		},
+	}
+
+	t.Parallel()
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			s := newState(tt.environment, "valid", tt.arguments...)
+			defer s.Logger().ShutdownLoggers()
+			require.NoError(t, s.ParseArguments())
+
+			cmd, err := s.Command()
+			require.NoError(t, err)
+			assert.Equal(t, "auth", cmd.GetName())
+			assert.Equal(t, tt.exitcode, cmd.Run())
+			assert.Equal(t, tt.stdout, s.Stdout.(*bytes.Buffer).String())
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package state
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	blockBegin = "# BEGIN update-gitignore: "
+	blockEnd   = "# END update-gitignore: "
+)
+
+// Block is a section of a gitignore file managed by update-gitignore. Blocks are delimited by marker comments
+// that record which template the block came from:
+//
+//	# BEGIN update-gitignore: Go repo=github/gitignore path=Go.gitignore sha=<blob sha>
+//	...
+//	# END update-gitignore: Go
+type Block struct {
+	Name string
+	Repo string
+	Path string
+	SHA  string
+
+	// Begin and End are the indexes into Gitignore.Lines of the marker lines.
+	Begin int
+	End   int
+}
+
+// Header renders the marker line that begins the block.
+func (b *Block) Header() string {
+	var fields []string
+	for _, kv := range [][2]string{{"repo", b.Repo}, {"path", b.Path}, {"sha", b.SHA}} {
+		if kv[1] != "" {
+			fields = append(fields, kv[0]+"="+kv[1])
+		}
+	}
+
+	return strings.TrimSpace(blockBegin + b.Name + " " + strings.Join(fields, " "))
+}
+
+// Footer renders the marker line that ends the block.
+func (b *Block) Footer() string {
+	return blockEnd + b.Name
+}
+
+// Contents returns the lines between the block markers.
+func (b *Block) Contents(g *Gitignore) []*Line {
+	return g.Lines[b.Begin+1 : b.End]
+}
+
+// parseBlockHeader parses a begin marker. Returns nil if the line is not a begin marker.
+func parseBlockHeader(text string) *Block {
+	if !strings.HasPrefix(text, blockBegin) {
+		return nil
+	}
+
+	fields := strings.Fields(strings.TrimPrefix(text, blockBegin))
+	if len(fields) == 0 {
+		return nil
+	}
+
+	b := &Block{Name: fields[0]}
+	for _, field := range fields[1:] {
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		switch kv[0] {
+		case "repo":
+			b.Repo = kv[1]
+		case "path":
+			b.Path = kv[1]
+		case "sha":
+			b.SHA = kv[1]
+		}
+	}
+
+	return b
+}
+
+// Blocks returns the managed blocks in the file, in order. Returns an error if the markers are unbalanced.
+func (g *Gitignore) Blocks() ([]*Block, error) {
+	var (
+		blocks  []*Block
+		current *Block
+	)
+
+	for idx, line := range g.Lines {
+		if line.Kind != CommentLine {
+			continue
+		}
+
+		if b := parseBlockHeader(line.Text); b != nil {
+			if current != nil {
+				return nil, fmt.Errorf("line %d: block %s begins inside block %s", line.Number, b.Name, current.Name)
+			}
+			b.Begin = idx
+			current = b
+			continue
+		}
+
+		if strings.HasPrefix(line.Text, blockEnd) {
+			name := strings.TrimSpace(strings.TrimPrefix(line.Text, blockEnd))
+			if current == nil {
+				return nil, fmt.Errorf("line %d: block %s ends without beginning", line.Number, name)
+			}
+			if name != current.Name {
+				return nil, fmt.Errorf("line %d: block %s ends inside block %s", line.Number, name, current.Name)
+			}
+			current.End = idx
+			blocks = append(blocks, current)
+			current = nil
+		}
+	}
+
+	if current != nil {
+		return nil, fmt.Errorf("line %d: block %s is not terminated", g.Lines[current.Begin].Number, current.Name)
+	}
+
+	return blocks, nil
+}
+
+// NewGitignoreMatcher returns a Matcher for a gitignore file at the root of the tree, attributing the rules inside
+// managed blocks to the name of their block.
+func NewGitignoreMatcher(g *Gitignore, source string) (*Matcher, error) {
+	blocks, err := g.Blocks()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", source, err)
+	}
+
+	names := blockNames(blocks)
+	m := new(Matcher)
+	for idx, line := range g.Lines {
+		if line.Kind == PatternLine {
+			m.AddRule(&Rule{Line: line, Source: source, Block: names[idx]})
+		}
+	}
+
+	return m, nil
+}
+
+// blockNames maps each line index to the name of the block containing it.
+func blockNames(blocks []*Block) map[int]string {
+	names := make(map[int]string)
+	for _, b := range blocks {
+		for idx := b.Begin + 1; idx < b.End; idx++ {
+			names[idx] = b.Name
+		}
+	}
+	return names
+}
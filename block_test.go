@@ -0,0 +1,89 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGitignore_Blocks(t *testing.T) {
+	cases := []struct {
+		name   string
+		text   string
+		blocks []*Block
+		err    *string
+	}{
+		{
+			"none",
+			"*.o\n",
+			nil,
+			nil,
+		},
+		{
+			"two blocks",
+			chain(
+				"# local\n",
+				"/secrets\n",
+				"# BEGIN update-gitignore: Go repo=github/gitignore path=Go.gitignore sha=abc123\n",
+				"*.exe\n",
+				"# END update-gitignore: Go\n",
+				"# BEGIN update-gitignore: macOS\n",
+				".DS_Store\n",
+				"# END update-gitignore: macOS\n",
+			),
+			[]*Block{
+				{Name: "Go", Repo: "github/gitignore", Path: "Go.gitignore", SHA: "abc123", Begin: 2, End: 4},
+				{Name: "macOS", Begin: 5, End: 7},
+			},
+			nil,
+		},
+		{
+			"unterminated",
+			"# BEGIN update-gitignore: Go\n*.exe\n",
+			nil,
+			strptr("line 1: block Go is not terminated"),
+		},
+		{
+			"nested",
+			"# BEGIN update-gitignore: Go\n# BEGIN update-gitignore: Node\n",
+			nil,
+			strptr("line 2: block Node begins inside block Go"),
+		},
+		{
+			"mismatched",
+			"# BEGIN update-gitignore: Go\n# END update-gitignore: Node\n",
+			nil,
+			strptr("line 2: block Node ends inside block Go"),
+		},
+		{
+			"orphan end",
+			"# END update-gitignore: Go\n",
+			nil,
+			strptr("line 1: block Go ends without beginning"),
+		},
+	}
+
+	t.Parallel()
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			blocks, err := ParseGitignoreString(tt.text).Blocks()
+			assert.Equal(t, tt.blocks, blocks)
+			errEquals(t, tt.err, err)
+		})
+	}
+}
+
+func TestBlock_Header(t *testing.T) {
+	b := &Block{Name: "Go", Repo: "github/gitignore", Path: "Go.gitignore", SHA: "abc123"}
+	header := b.Header()
+	assert.Equal(t, "# BEGIN update-gitignore: Go repo=github/gitignore path=Go.gitignore sha=abc123", header)
+	assert.Equal(t, "# END update-gitignore: Go", b.Footer())
+
+	parsed := parseBlockHeader(header)
+	require.NotNil(t, parsed)
+	assert.Equal(t, b, parsed)
+
+	assert.Equal(t, "# BEGIN update-gitignore: Go", (&Block{Name: "Go"}).Header())
+}
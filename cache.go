@@ -0,0 +1,73 @@
+package state
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// cacheDir returns the base directory for cached data, or the empty string if there is none.
+func (s *State) cacheDir() string {
+	if dir, _ := s.LookupEnv("XDG_CACHE_HOME"); dir != "" {
+		return dir
+	}
+
+	if home := s.homeDir(); home != "" {
+		return filepath.Join(home, ".cache")
+	}
+
+	return ""
+}
+
+// catalogCachePath returns where the catalog of the repository at ref is cached.
+func (s *State) catalogCachePath(repo, ref string) string {
+	dir := s.cacheDir()
+	if dir == "" {
+		return ""
+	}
+
+	return filepath.Join(dir, "update-gitignore", filepath.FromSlash(repo), strings.Replace(ref, "/", "_", -1)+".json")
+}
+
+// writeCatalogCache saves the catalog so it can be used without a network request, e.g. for shell completion.
+// Failures are only logged since the cache is optional.
+func (s *State) writeCatalogCache(cat *Catalog) {
+	name := s.catalogCachePath(cat.Repo, cat.Ref)
+	if name == "" {
+		return
+	}
+
+	buf, err := json.Marshal(cat)
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(name), 0755)
+	}
+	if err == nil {
+		err = ioutil.WriteFile(name, buf, 0644)
+	}
+	if err != nil {
+		s.Logger().Debugf("caching catalog: %v", err)
+	}
+}
+
+// CachedCatalog returns the catalog of the repository at ref saved by the last fetch, or nil if there is none.
+func (s *State) CachedCatalog(repo, ref string) *Catalog {
+	name := s.catalogCachePath(repo, ref)
+	if name == "" {
+		return nil
+	}
+
+	buf, err := ioutil.ReadFile(name)
+	if err != nil {
+		return nil
+	}
+
+	cat := new(Catalog)
+	if err := json.Unmarshal(buf, cat); err != nil {
+		s.Logger().Debugf("%s: %v", name, err)
+		return nil
+	}
+
+	return cat
+}
@@ -0,0 +1,188 @@
+package state
+
+import (
+	"encoding/base64"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/v24/github"
+)
+
+// DefaultRef is the branch of the template repository used when no other ref is configured.
+const DefaultRef = "master"
+
+// Catalog is the set of templates available in a template repository at a commit.
+type Catalog struct {
+	Repo      string
+	Ref       string
+	Commit    string
+	Templates []*Template
+}
+
+// Catalog walks the repository tree at the tip of ref and collects every template. Directories starting with a
+// dot are skipped.
+func (c *Client) Catalog(ref string) (*Catalog, error) {
+	branch, err := c.GetBranch(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	cat := &Catalog{
+		Repo:   c.owner + "/" + c.repo,
+		Ref:    ref,
+		Commit: branch.GetCommit().GetSHA(),
+	}
+
+	if err := c.walk(cat, cat.Commit, ""); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(cat.Templates, func(i, j int) bool {
+		return strings.ToLower(cat.Templates[i].Path) < strings.ToLower(cat.Templates[j].Path)
+	})
+
+	return cat, nil
+}
+
+func (c *Client) walk(cat *Catalog, sha, prefix string) error {
+	tree, err := c.GetTree(sha)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range tree.Entries {
+		entry.Path = github.String(path.Join(prefix, entry.GetPath()))
+
+		switch entry.GetType() {
+		case "tree":
+			if strings.HasPrefix(path.Base(entry.GetPath()), ".") {
+				continue
+			}
+			if err := c.walk(cat, entry.GetSHA(), entry.GetPath()); err != nil {
+				return err
+			}
+		case "blob":
+			if t := NewTemplate(entry); t != nil {
+				if prefix != "" {
+					t.Tags = strings.Split(prefix, "/")
+				}
+				cat.Templates = append(cat.Templates, t)
+			}
+		}
+	}
+
+	return nil
+}
+
+// GetTemplate downloads the contents of the template.
+func (c *Client) GetTemplate(t *Template) (string, error) {
+	blob, err := c.GetBlob(t.SHA)
+	if err != nil {
+		return "", err
+	}
+
+	if blob.GetEncoding() != "base64" {
+		return blob.GetContent(), nil
+	}
+
+	buf, err := base64.StdEncoding.DecodeString(strings.Replace(blob.GetContent(), "\n", "", -1))
+	if err != nil {
+		return "", fmt.Errorf("%s: %v", t.Path, err)
+	}
+
+	return string(buf), nil
+}
+
+// Find returns the template with the given name. The name may be the template name or its path without the
+// suffix, e.g. "macOS" or "Global/macOS", and is compared case-insensitively if there is no exact match. Top
+// level templates are preferred over ones in subdirectories when names collide.
+func (cat *Catalog) Find(name string) *Template {
+	name = strings.TrimSuffix(name, Suffix)
+
+	var fold *Template
+	for _, t := range cat.byDepth() {
+		full := strings.TrimSuffix(t.Path, Suffix)
+		if t.Name == name || full == name {
+			return t
+		}
+		if fold == nil && (strings.EqualFold(t.Name, name) || strings.EqualFold(full, name)) {
+			fold = t
+		}
+	}
+
+	return fold
+}
+
+// Resolve finds each of the named templates, returning an error naming the first that doesn't exist. Templates
+// named more than once are only returned once.
+func (cat *Catalog) Resolve(names ...string) ([]*Template, error) {
+	var (
+		rv   = make([]*Template, 0, len(names))
+		seen = make(map[*Template]bool)
+	)
+
+	for _, name := range names {
+		t := cat.Find(name)
+		if t == nil {
+			return nil, fmt.Errorf("template %s not found in %s", name, cat.Repo)
+		}
+		if !seen[t] {
+			seen[t] = true
+			rv = append(rv, t)
+		}
+	}
+
+	return rv, nil
+}
+
+func (cat *Catalog) byDepth() []*Template {
+	rv := make([]*Template, len(cat.Templates))
+	copy(rv, cat.Templates)
+	sort.SliceStable(rv, func(i, j int) bool { return len(rv[i].Tags) < len(rv[j].Tags) })
+	return rv
+}
+
+// Catalog fetches the catalog of the configured template repository.
+func (s *State) Catalog() (*Catalog, error) {
+	return s.CatalogAt(s.repo, DefaultRef)
+}
+
+// catalogFrom returns the catalog of the template repository at ref from the catalogs fetched so far, fetching and
+// adding it if it's missing, so that every file planned in one run sees the same commit.
+func (s *State) catalogFrom(catalogs map[string]*Catalog, repo, ref string) (*Catalog, error) {
+	key := catalogKey(repo, ref)
+	if cat, ok := catalogs[key]; ok {
+		return cat, nil
+	}
+
+	cat, err := s.CatalogAt(repo, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	catalogs[key] = cat
+	return cat, nil
+}
+
+// catalogKey identifies the catalog of the template repository at ref among the catalogs fetched so far.
+func catalogKey(repo, ref string) string {
+	return repo + "@" + ref
+}
+
+// CatalogAt fetches the catalog of the template repository at the tip of ref and caches it.
+func (s *State) CatalogAt(repo, ref string) (*Catalog, error) {
+	cl, err := s.clientFor(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	cat, err := cl.Catalog(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	s.writeCatalogCache(cat)
+	return cat, nil
+}
@@ -0,0 +1,71 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_Catalog(t *testing.T) {
+	a, c := newClient(nil, "valid")
+	defer a.Logger().ShutdownLoggers()
+
+	cat, err := c.Catalog("master")
+	require.NoError(t, err)
+	assert.Equal(t, "github/gitignore", cat.Repo)
+	assert.Equal(t, "master", cat.Ref)
+	assert.Equal(t, "56e3f5a7b2a67413a1d3e33fceb8100898015a2e", cat.Commit)
+
+	cases := []struct {
+		name string
+		path string
+		tags []string
+	}{
+		{"Go", "Go.gitignore", nil},
+		{"go", "Go.gitignore", nil},
+		{"Go.gitignore", "Go.gitignore", nil},
+		{"macOS", "Global/macOS.gitignore", []string{"Global"}},
+		{"Global/macOS", "Global/macOS.gitignore", []string{"Global"}},
+		{"Hugo", "community/Golang/Hugo.gitignore", []string{"community", "Golang"}},
+		{"Snap", "community/Linux/Snap.gitignore", []string{"community", "Linux"}},
+		{"Java", "Java.gitignore", nil},
+		{"community/Java/JBoss4", "community/Java/JBoss4.gitignore", []string{"community", "Java"}},
+		{"NotATemplate", "", nil},
+	}
+
+	for _, tt := range cases {
+		tpl := cat.Find(tt.name)
+		if tt.path == "" {
+			assert.Nil(t, tpl, tt.name)
+			continue
+		}
+
+		if assert.NotNil(t, tpl, tt.name) {
+			assert.Equal(t, tt.path, tpl.Path, tt.name)
+			assert.Equal(t, tt.tags, tpl.Tags, tt.name)
+		}
+	}
+
+	templates, err := cat.Resolve("Go", "go", "macOS")
+	require.NoError(t, err)
+	assert.Len(t, templates, 2)
+
+	_, err = cat.Resolve("Go", "Nope")
+	assert.EqualError(t, err, "template Nope not found in github/gitignore")
+}
+
+func TestClient_GetTemplate(t *testing.T) {
+	a, c := newClient(nil, "valid")
+	defer a.Logger().ShutdownLoggers()
+
+	text, err := c.GetTemplate(&Template{Name: "VisualStudioCode", SHA: "0511e2b51f0d42d1dff69f4ed5df03c6649ca356"})
+	require.NoError(t, err)
+	assert.Equal(t, chain(
+		".vscode/*\n",
+		"!.vscode/settings.json\n",
+		"!.vscode/tasks.json\n",
+		"!.vscode/launch.json\n",
+		"!.vscode/extensions.json\n",
+	), text)
+}
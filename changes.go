@@ -0,0 +1,250 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v24/github"
+)
+
+type (
+	changesCommand State
+
+	// TemplateChanges are the upstream changes to the template of a managed block since it was fetched.
+	TemplateChanges struct {
+		Name string `json:"name"`
+		Path string `json:"path"`
+		// From is the blob of the managed block and To the blob of the current template.
+		From string `json:"from"`
+		To   string `json:"to"`
+		// Commits are the commits that touched the template, newest first. They are only known if the lockfile
+		// records the commit the block was fetched from.
+		Commits []*CommitSummary `json:"commits"`
+		Diff    string           `json:"diff"`
+	}
+)
+
+func (c *changesCommand) GetName() string { return "changes" }
+
+// Run prints the commits and the changes to each named template since its managed block was fetched.
+func (c *changesCommand) Run() ExitStatus {
+	s := (*State)(c)
+	logger := s.Logger()
+
+	changes, err := s.Changes(s.templates...)
+	if err != nil {
+		logger.Error(err.Error())
+		return 1
+	}
+
+	if s.format == "json" {
+		enc := json.NewEncoder(s.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(changes)
+	} else {
+		for idx, tc := range changes {
+			if idx > 0 {
+				if _, err = fmt.Fprintln(s.Stdout); err != nil {
+					break
+				}
+			}
+			if err = tc.write(s); err != nil {
+				break
+			}
+		}
+	}
+
+	if err != nil {
+		logger.Error(err.Error())
+		return 1
+	}
+
+	return 0
+}
+
+// Changes compares the named managed blocks, or every block fetched from the configured repository if none are
+// named, with the current templates.
+func (s *State) Changes(names ...string) ([]*TemplateChanges, error) {
+	g, err := s.ReadGitignore(GitignoreFile)
+	if err != nil {
+		return nil, err
+	}
+
+	blocks, err := g.Blocks()
+	if err != nil {
+		return nil, err
+	}
+
+	lock, err := s.ReadLock()
+	if err != nil {
+		return nil, err
+	}
+
+	cat, err := s.Catalog()
+	if err != nil {
+		return nil, err
+	}
+
+	cl, err := s.clientFor(cat.Repo)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(names) == 0 {
+		for _, b := range blocks {
+			if b.Path != "" && b.Repo == cat.Repo {
+				names = append(names, b.Name)
+			}
+		}
+	}
+
+	// the commits since the lockfile was written, if it records them
+	var since map[string]bool
+	if lock == nil || lock.Repo != cat.Repo || lock.Commit == "" {
+		s.Logger().Warnf("%s doesn't record the commit the blocks were fetched from; only showing differences", LockFile)
+	} else if since, err = s.commitsSince(cl, lock.Commit, cat.Commit); err != nil {
+		return nil, err
+	}
+
+	var rv []*TemplateChanges
+	for _, name := range names {
+		b := findBlock(blocks, name)
+		if b == nil || b.Path == "" {
+			return nil, fmt.Errorf("%s: no managed block for a template named %s", GitignoreFile, name)
+		}
+		if b.Repo != cat.Repo {
+			return nil, fmt.Errorf("%s: block %s is from %s, not %s", GitignoreFile, b.Name, b.Repo, cat.Repo)
+		}
+
+		tc := &TemplateChanges{Name: b.Name, Path: b.Path, From: b.SHA, Commits: []*CommitSummary{}}
+		if lock != nil {
+			if locked := lock.Find(b.Name); locked != nil {
+				tc.From = locked.SHA
+			}
+		}
+
+		t := cat.Find(strings.TrimSuffix(b.Path, Suffix))
+		if t == nil || t.Path != b.Path {
+			return nil, fmt.Errorf("template %s no longer exists in %s", b.Path, cat.Repo)
+		}
+		tc.To = t.SHA
+
+		if tc.From != tc.To {
+			if err := s.compareTemplate(cl, cat, tc, since); err != nil {
+				return nil, err
+			}
+		}
+
+		rv = append(rv, tc)
+	}
+
+	return rv, nil
+}
+
+// maxComparePages caps the pages of a comparison read by commitsSince, so a lockfile far behind upstream costs a
+// bounded number of requests.
+const maxComparePages = 10
+
+// commitsSince returns the set of commits reachable from head but not from base, paging through the comparison.
+func (s *State) commitsSince(cl *Client, base, head string) (map[string]bool, error) {
+	rv := make(map[string]bool)
+	if base == head {
+		return rv, nil
+	}
+
+	var total int
+	for page, read := 1, 0; page != 0 && read < maxComparePages; read++ {
+		cmp, next, err := cl.CompareCommits(base, head, page)
+		if err != nil {
+			return nil, err
+		}
+
+		total = cmp.GetTotalCommits()
+		for _, commit := range cmp.Commits {
+			rv[commit.GetSHA()] = true
+		}
+		page = next
+	}
+
+	if len(rv) < total {
+		s.Logger().Warnf("only %d of the %d commits since %s could be listed; the list of commits may be incomplete",
+			len(rv), total, shortSHA(base))
+	}
+	return rv, nil
+}
+
+// compareTemplate fills in the commits that touched the template and the diff between the blobs.
+func (s *State) compareTemplate(cl *Client, cat *Catalog, tc *TemplateChanges, since map[string]bool) error {
+	old, err := cl.GetTemplate(&Template{Path: tc.Path, SHA: tc.From})
+	if err != nil {
+		return err
+	}
+
+	current, err := cl.GetTemplate(&Template{Path: tc.Path, SHA: tc.To})
+	if err != nil {
+		return err
+	}
+
+	tc.Diff = UnifiedDiff("a/"+tc.Path, "b/"+tc.Path, old, current, 3)
+
+	if since == nil {
+		return nil
+	}
+
+	// the path's history is newest first, so stop at the first page that is entirely older than the lockfile
+	for page := 1; page != 0; {
+		commits, next, err := cl.ListCommits(cat.Commit, tc.Path, page)
+		if err != nil {
+			return err
+		}
+
+		found := false
+		for _, commit := range commits {
+			if since[commit.GetSHA()] {
+				found = true
+				tc.Commits = append(tc.Commits, newCommitSummary(commit))
+			}
+		}
+
+		if !found {
+			break
+		}
+		page = next
+	}
+
+	return nil
+}
+
+func newCommitSummary(commit *github.RepositoryCommit) *CommitSummary {
+	author := commit.GetCommit().GetAuthor()
+	return &CommitSummary{
+		SHA:     commit.GetSHA(),
+		Author:  author.GetName(),
+		Email:   author.GetEmail(),
+		Date:    author.GetDate(),
+		Message: strings.Replace(commit.GetCommit().GetMessage(), "\r\n", "\n", -1),
+	}
+}
+
+// write prints the changes like git log -p.
+func (tc *TemplateChanges) write(s *State) error {
+	if tc.From == tc.To {
+		_, err := fmt.Fprintf(s.Stdout, "%s: %s is up to date (%s)\n", tc.Name, tc.Path, shortSHA(tc.To))
+		return err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: %s %s..%s\n", tc.Name, tc.Path, shortSHA(tc.From), shortSHA(tc.To))
+	for _, c := range tc.Commits {
+		fmt.Fprintf(&b, "\ncommit %s\nAuthor: %s <%s>\nDate:   %s\n\n", c.SHA, c.Author, c.Email, c.Date.Format(time.RFC3339))
+		for _, line := range strings.Split(strings.TrimRight(c.Message, "\n"), "\n") {
+			fmt.Fprintln(&b, strings.TrimRight("    "+line, " "))
+		}
+	}
+	fmt.Fprintf(&b, "\n%s", tc.Diff)
+
+	_, err := fmt.Fprint(s.Stdout, b.String())
+	return err
+}
@@ -0,0 +1,163 @@
+package state
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// staleLinux is the Linux block as if it had been fetched when the template had the content of macOS
+	staleLinux = "# BEGIN update-gitignore: Linux repo=github/gitignore path=Global/Linux.gitignore sha=135767fc075ec33f7f9966fb28968113e32b697e\n" +
+		".DS_Store\n" +
+		"# END update-gitignore: Linux\n"
+	currentGo = "# BEGIN update-gitignore: Go repo=github/gitignore path=Go.gitignore sha=f2dd9554a12fd7acdc62e60e8eccae086f718be2\n" +
+		"*.exe\n" +
+		"# END update-gitignore: Go\n"
+	parentCommit = "6d467f5ebe94f260e5043767f88216d13bafca62"
+	masterCommit = "56e3f5a7b2a67413a1d3e33fceb8100898015a2e"
+)
+
+func TestChangesCommand_Run(t *testing.T) {
+	cases := []struct {
+		name     string
+		lock     bool
+		args     []string
+		stdout   []string
+		stderr   string
+		exitcode ExitStatus
+	}{
+		{
+			"locked",
+			true,
+			[]string{"changes"},
+			[]string{
+				"Go: Go.gitignore is up to date (f2dd955)\n\nLinux: Global/Linux.gitignore 135767f..b56bf65\n\ncommit " + masterCommit + "\n",
+				"Author: Lucas Steer <LucasSteer@users.noreply.github.com>\nDate:   2019-03-23T18:29:17Z\n\n",
+				"    [Unity] Added leading slashes to ignored directories so that valid subdirectories aren't ignored incorrectly (#2980)\n\n",
+				"    * Added a leading slash to never ignore .meta files in the root Asset folder\n\n",
+				"--- a/Global/Linux.gitignore\n+++ b/Global/Linux.gitignore\n",
+				"+.Trash-*\n",
+			},
+			"",
+			0,
+		},
+		{
+			"unlocked",
+			false,
+			[]string{"changes", "Linux"},
+			[]string{"Linux: Global/Linux.gitignore 135767f..b56bf65\n\n--- a/Global/Linux.gitignore\n"},
+			".gitignore.lock doesn't record the commit the blocks were fetched from; only showing differences",
+			0,
+		},
+		{
+			"unknown block",
+			true,
+			[]string{"changes", "Node"},
+			nil,
+			".gitignore: no managed block for a template named Node",
+			1,
+		},
+	}
+
+	t.Parallel()
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			dir, cleanup := tempDir(t)
+			defer cleanup()
+
+			writeFile(t, filepath.Join(dir, ".gitignore"), currentGo+"\n"+staleLinux)
+
+			s := newState(nil, "valid", append([]string{"-C", dir}, tt.args...)...)
+			require.NoError(t, s.ParseArguments())
+			if tt.lock {
+				require.NoError(t, s.WriteLock(&Lock{
+					Version: LockVersion,
+					Repo:    "github/gitignore",
+					Ref:     "master",
+					Commit:  parentCommit,
+					Templates: []LockedTemplate{
+						{Name: "Go", Path: "Go.gitignore", SHA: "f2dd9554a12fd7acdc62e60e8eccae086f718be2"},
+						{Name: "Linux", Path: "Global/Linux.gitignore", SHA: "135767fc075ec33f7f9966fb28968113e32b697e"},
+					},
+				}))
+			}
+
+			cmd, err := s.Command()
+			require.NoError(t, err)
+			assert.Equal(t, "changes", cmd.GetName())
+			assert.Equal(t, tt.exitcode, cmd.Run())
+			require.NoError(t, s.Logger().ShutdownLoggers())
+
+			stdout := s.Stdout.(*bytes.Buffer).String()
+			for _, want := range tt.stdout {
+				assert.Contains(t, stdout, want)
+			}
+			if tt.stdout == nil {
+				assert.Empty(t, stdout)
+			}
+			assert.Contains(t, s.Stderr.(*bytes.Buffer).String(), tt.stderr)
+		})
+	}
+}
+
+func TestState_Changes(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	writeFile(t, filepath.Join(dir, ".gitignore"), staleLinux)
+
+	s := newState(nil, "valid", "-C", dir, "-format", "json", "changes", "linux")
+	defer s.Logger().ShutdownLoggers()
+	require.NoError(t, s.ParseArguments())
+	require.NoError(t, s.WriteLock(&Lock{Version: LockVersion, Repo: "github/gitignore", Ref: "master", Commit: parentCommit}))
+
+	cmd, err := s.Command()
+	require.NoError(t, err)
+	require.Equal(t, ExitStatus(0), cmd.Run())
+
+	var changes []*TemplateChanges
+	require.NoError(t, json.Unmarshal(s.Stdout.(*bytes.Buffer).Bytes(), &changes))
+	require.Len(t, changes, 1)
+	assert.Equal(t, "Linux", changes[0].Name)
+	assert.Equal(t, "135767fc075ec33f7f9966fb28968113e32b697e", changes[0].From)
+	assert.Equal(t, "b56bf65d85583b03eeccfaa2a927084583a33e91", changes[0].To)
+	require.Len(t, changes[0].Commits, 1)
+	assert.Equal(t, masterCommit, changes[0].Commits[0].SHA)
+	assert.Contains(t, changes[0].Diff, "-.DS_Store\n")
+
+	// without changes since the lockfile there are no commits to list
+	commits, err := s.commitsSince(nil, masterCommit, masterCommit)
+	require.NoError(t, err)
+	assert.Empty(t, commits)
+}
+
+func TestState_commitsSince_Truncated(t *testing.T) {
+	s := newState(nil, "valid", "changes")
+	require.NoError(t, s.ParseArguments())
+
+	var requests int
+	replay := s.httpClient.Transport
+	s.httpClient.Transport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		requests++
+		return replay.RoundTrip(req)
+	})
+
+	cl, err := s.Client()
+	require.NoError(t, err)
+
+	// every recorded page of the comparison lists the same one of 300 commits and links to another
+	base := "1111111111111111111111111111111111111111"
+	commits, err := s.commitsSince(cl, base, masterCommit)
+	require.NoError(t, err)
+	require.NoError(t, s.Logger().ShutdownLoggers())
+	assert.Equal(t, map[string]bool{masterCommit: true}, commits)
+	assert.Equal(t, maxComparePages, requests)
+	assert.Contains(t, s.Stderr.(*bytes.Buffer).String(), "only 1 of the 300 commits since 1111111 could be listed; the list of commits may be incomplete")
+}
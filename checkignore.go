@@ -0,0 +1,175 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+type (
+	checkIgnoreCommand State
+
+	// IgnoreMatch describes the rule deciding whether a path is ignored.
+	IgnoreMatch struct {
+		Path     string `json:"path"`
+		Ignored  bool   `json:"ignored"`
+		Source   string `json:"source,omitempty"`
+		Line     int    `json:"line,omitempty"`
+		Pattern  string `json:"pattern,omitempty"`
+		Template string `json:"template,omitempty"`
+	}
+)
+
+func (c *checkIgnoreCommand) GetName() string { return "check-ignore" }
+
+// Run prints the matching rule for each path that matches a rule, similar to git check-ignore -v. Returns 0 if any
+// path is ignored, 1 if none are and 2 on error.
+func (c *checkIgnoreCommand) Run() ExitStatus {
+	s := (*State)(c)
+	logger := s.Logger()
+
+	if len(s.templates) == 0 {
+		logger.Error("check-ignore requires at least one path")
+		return 2
+	}
+
+	matches, err := s.CheckIgnore(s.templates...)
+	if err != nil {
+		logger.Error(err.Error())
+		return 2
+	}
+
+	if s.format == "json" {
+		enc := json.NewEncoder(s.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(matches); err != nil {
+			logger.Error(err.Error())
+			return 2
+		}
+	}
+
+	var status ExitStatus = 1
+	for _, m := range matches {
+		if m.Ignored {
+			status = 0
+		}
+
+		if s.format != "json" && m.Source != "" {
+			template := m.Template
+			if template == "" {
+				template = "-"
+			}
+			fmt.Fprintf(s.Stdout, "%s:%d:%s\t%s\t%s\n", m.Source, m.Line, m.Pattern, template, m.Path)
+		}
+	}
+
+	return status
+}
+
+// CheckIgnore evaluates each path, relative to the working directory, against the files git consults for it:
+// core.excludesFile, .git/info/exclude and the gitignore files of the working directory and of each directory
+// leading to the path. The working directory is treated as the top of the tree.
+func (s *State) CheckIgnore(paths ...string) ([]IgnoreMatch, error) {
+	m, err := s.ignoreMatcher(paths)
+	if err != nil {
+		return nil, err
+	}
+
+	rv := make([]IgnoreMatch, len(paths))
+	for idx, name := range paths {
+		isDir := strings.HasSuffix(name, "/")
+		if !isDir {
+			if st, err := os.Stat(s.Path(name)); err == nil {
+				isDir = st.IsDir()
+			}
+		}
+
+		rv[idx] = newIgnoreMatch(name, m.Match(name, isDir))
+	}
+
+	return rv, nil
+}
+
+// ignoreMatcher returns a Matcher with the rules of the files git consults for the paths, in increasing precedence.
+// The exclude files are skipped if there are none, e.g. outside of a git repository.
+func (s *State) ignoreMatcher(paths []string) (*Matcher, error) {
+	m := new(Matcher)
+	add := func(base, name string) error {
+		g, err := s.ReadGitignore(name)
+		if err != nil {
+			return err
+		}
+
+		fm, err := NewGitignoreMatcher(g, name)
+		if err != nil {
+			return err
+		}
+
+		for _, rule := range fm.Rules() {
+			rule.Base = base
+			m.AddRule(rule)
+		}
+		return nil
+	}
+
+	for _, target := range []string{"global", "exclude"} {
+		if name, err := s.TargetFile(target); err == nil {
+			if err := add("", name); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := add("", GitignoreFile); err != nil {
+		return nil, err
+	}
+
+	for _, dir := range parentDirs(paths) {
+		if err := add(dir, path.Join(dir, GitignoreFile)); err != nil {
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
+
+// parentDirs returns the slash separated directories leading to the paths, parents before their children.
+func parentDirs(paths []string) []string {
+	seen := make(map[string]bool)
+	for _, name := range paths {
+		name = strings.Trim(path.Clean("/"+filepath.ToSlash(name)), "/")
+		for dir := path.Dir(name); dir != "." && dir != "" && !seen[dir]; dir = path.Dir(dir) {
+			seen[dir] = true
+		}
+	}
+
+	rv := make([]string, 0, len(seen))
+	for dir := range seen {
+		rv = append(rv, dir)
+	}
+	sort.Slice(rv, func(i, j int) bool {
+		if di, dj := strings.Count(rv[i], "/"), strings.Count(rv[j], "/"); di != dj {
+			return di < dj
+		}
+		return rv[i] < rv[j]
+	})
+
+	return rv
+}
+
+// newIgnoreMatch describes the rule deciding whether the path is ignored. The rule may be nil.
+func newIgnoreMatch(name string, rule *Rule) IgnoreMatch {
+	rv := IgnoreMatch{Path: name}
+	if rule != nil {
+		rv.Ignored = !rule.Pattern.Negated
+		rv.Source = rule.Source
+		rv.Line = rule.Number
+		rv.Pattern = trimTrailingSpaces(rule.Text)
+		rv.Template = rule.Block
+	}
+	return rv
+}
@@ -0,0 +1,140 @@
+package state
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckIgnoreCommand_Run(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	writeFile(t, filepath.Join(dir, ".gitignore"), chain(
+		"/secrets\n",
+		"# BEGIN update-gitignore: Go repo=github/gitignore path=Go.gitignore sha=abc123\n",
+		"*.exe\n",
+		"vendor/\n",
+		"# END update-gitignore: Go\n",
+		"# BEGIN update-gitignore: Node\n",
+		"*.log\n",
+		"!keep.log\n",
+		"# END update-gitignore: Node\n",
+	))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "vendor"), 0755))
+
+	cases := []struct {
+		name     string
+		args     []string
+		stdout   string
+		exitcode ExitStatus
+	}{
+		{
+			"text",
+			[]string{"check-ignore", "secrets", "app.exe", "vendor", "keep.log", "main.go"},
+			chain(
+				".gitignore:1:/secrets\t-\tsecrets\n",
+				".gitignore:3:*.exe\tGo\tapp.exe\n",
+				".gitignore:4:vendor/\tGo\tvendor\n",
+				".gitignore:8:!keep.log\tNode\tkeep.log\n",
+			),
+			0,
+		},
+		{
+			"none ignored",
+			[]string{"check-ignore", "main.go", "keep.log"},
+			".gitignore:8:!keep.log\tNode\tkeep.log\n",
+			1,
+		},
+		{
+			"json",
+			[]string{"-format=json", "check-ignore", "debug.log", "main.go"},
+			chain(
+				"[\n",
+				"  {\n",
+				"    \"path\": \"debug.log\",\n",
+				"    \"ignored\": true,\n",
+				"    \"source\": \".gitignore\",\n",
+				"    \"line\": 7,\n",
+				"    \"pattern\": \"*.log\",\n",
+				"    \"template\": \"Node\"\n",
+				"  },\n",
+				"  {\n",
+				"    \"path\": \"main.go\",\n",
+				"    \"ignored\": false\n",
+				"  }\n",
+				"]\n",
+			),
+			0,
+		},
+		{
+			"no paths",
+			[]string{"check-ignore"},
+			"",
+			2,
+		},
+	}
+
+	t.Parallel()
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			s := &State{App: newApp(nil, append([]string{"-C", dir}, tt.args...)...)}
+			defer s.Logger().ShutdownLoggers()
+			require.NoError(t, s.ParseArguments())
+
+			cmd, err := s.Command()
+			require.NoError(t, err)
+			assert.Equal(t, "check-ignore", cmd.GetName())
+			assert.Equal(t, tt.exitcode, cmd.Run())
+			assert.Equal(t, tt.stdout, s.Stdout.(*bytes.Buffer).String())
+		})
+	}
+}
+
+func TestState_CheckIgnore_Sources(t *testing.T) {
+	t.Parallel()
+
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	runGit(t, dir, "init", "-q")
+	writeFile(t, filepath.Join(dir, "xdg", "git", "ignore"), "*.swp\n")
+	writeFile(t, filepath.Join(dir, ".git", "info", "exclude"), "*.bak\n")
+	writeFile(t, filepath.Join(dir, ".gitignore"), "*.exe\n*.tmp\n")
+	writeFile(t, filepath.Join(dir, "sub", ".gitignore"), "!keep.exe\nbuild/\n")
+	writeFile(t, filepath.Join(dir, "sub", "deeper", ".gitignore"), "*.out\n")
+
+	s := &State{App: newApp([]string{"XDG_CONFIG_HOME=" + filepath.Join(dir, "xdg")}, "-C", dir, "check-ignore")}
+	defer s.Logger().ShutdownLoggers()
+	require.NoError(t, s.ParseArguments())
+
+	matches, err := s.CheckIgnore("a.swp", "a.bak", "sub/app.exe", "sub/keep.exe", "sub/deeper/x.out", "x.out", "sub/build/", "sub/deeper/y.tmp")
+	require.NoError(t, err)
+
+	var got []string
+	for _, m := range matches {
+		got = append(got, fmt.Sprintf("%s %v %s:%d:%s", m.Path, m.Ignored, filepath.ToSlash(m.Source), m.Line, m.Pattern))
+	}
+
+	assert.Equal(t, []string{
+		"a.swp true " + filepath.ToSlash(filepath.Join(dir, "xdg", "git", "ignore")) + ":1:*.swp",
+		"a.bak true .git/info/exclude:1:*.bak",
+		"sub/app.exe true .gitignore:1:*.exe",
+		"sub/keep.exe false sub/.gitignore:1:!keep.exe",
+		"sub/deeper/x.out true sub/deeper/.gitignore:1:*.out",
+		"x.out false :0:",
+		"sub/build/ true sub/.gitignore:2:build/",
+		"sub/deeper/y.tmp true .gitignore:2:*.tmp",
+	}, got)
+}
+
+func TestParentDirs(t *testing.T) {
+	assert.Equal(t, []string{"a", "b", "a/c", "a/c/d"}, parentDirs([]string{"a/c/d/e.txt", "b/f", "top", "./a/x"}))
+	assert.Equal(t, []string{}, parentDirs(nil))
+}
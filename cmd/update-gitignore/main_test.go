@@ -1,14 +1,20 @@
 package main
 
 import (
+	"bufio"
 	"bytes"
 	"context"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 
 	"github.com/demosdemon/golang-app-framework/app"
+	state "github.com/demosdemon/update-gitignore"
 )
 
 func TestMain(t *testing.T) {
@@ -23,10 +29,19 @@ func TestMain(t *testing.T) {
 	}{
 		{
 			"plain",
-			[]string{"list"},
+			[]string{"list", "go"},
 			[]string{},
 			"",
+			"community/Golang/Hugo\nGo\nGodot\nIGORPro\n",
 			"",
+			0,
+		},
+		{
+			"help",
+			[]string{"help", "completion"},
+			[]string{},
+			"",
+			"usage: update-gitignore [{flags}] completion bash|zsh|fish\n\nprints the shell completion script, e.g. source <(update-gitignore completion bash)\n",
 			"",
 			0,
 		},
@@ -45,22 +60,36 @@ func TestMain(t *testing.T) {
 			[]string{},
 			"",
 			"",
-			"usage: update-gitignore [{flags}] {action} [{template}...]\nActions:\n  dump - dumps the selected template(s) to STDOUT\n  list - lists the available templates, optionally filtered by the provided arguments\n\n{flags}    - Command line flags (see below)\n{template} - The Template to dump (required for \"dump\") or a search string to filter (optional for \"list\")\n\nExamples:\n  update-gitignore list go\n  update-gitignore -debug dump Go > .gitignore\n\nFlags:\n  -debug\n    \tprint debug statements to STDERR\n  -repo string\n    \tthe template repository to use (default \"github/gitignore\")\n  -timeout duration\n    \tthe max duration for network requests (0 for no timeout) (default 30s)\n[\x1b[31mERROR\x1b[0m] need an action {\"filename\":\"base.go\",\"lineno\":488,\"seq\":1}\n",
+			"usage: update-gitignore [{flags}] {action} [{action flags}] [{args}...]\nActions:\n  dump - dumps the selected template(s) to STDOUT\n  list - lists the available templates, optionally filtered by the provided arguments\n  show - prints a template's metadata, the last commit that changed it and its numbered content\n  auth - reports the authenticated user, token source, scopes and rate limits\n  check-ignore - explains which rule and template block in .gitignore ignores each path\n  lint - checks .gitignore, or the selected templates, for conflicting and redundant rules\n  update - refreshes the managed blocks in .gitignore, adding blocks for any named templates\n  diff - shows the changes update would make to .gitignore and the tracked files it would ignore\n  changes - lists the upstream commits and changes to the templates of managed blocks since they were fetched\n  detect - suggests templates based on marker files in the working tree\n  init - creates .gitignore and its lockfile from the detected and selected templates\n  add - adds managed blocks for the selected templates to .gitignore\n  remove - removes the managed blocks for the selected templates from .gitignore\n  config - shows the effective settings and where each was set\n  completion - prints the shell completion script, e.g. source <(update-gitignore completion bash)\n  help - shows the usage of an action\n\n{flags}        - Global flags (see below)\n{action flags} - Flags of the action, see \"update-gitignore help {action}\"\n{args}         - The arguments of the action, usually template names\n\nExamples:\n  update-gitignore list go\n  update-gitignore show Global/macOS\n  update-gitignore -debug dump Go > .gitignore\n  update-gitignore dump -dedupe Go Node VisualStudioCode > .gitignore\n  update-gitignore -format json auth status\n  update-gitignore check-ignore build/app.log\n  update-gitignore diff Node\n  update-gitignore changes Go\n  update-gitignore update $(update-gitignore detect -names)\n  update-gitignore update -recursive\n  update-gitignore init -yes JetBrains\n  update-gitignore add -after Go Node\n  UPDATE_GITIGNORE_TIMEOUT=1m update-gitignore config show\n  update-gitignore help dump\n\nFlags:\n  -C string\n    \trun as if started in this directory (default \".\")\n  -debug\n    \tprint debug statements to STDERR\n  -format string\n    \tthe output format (text, json, ndjson, table or a Go template for list) (default \"text\")\n  -repo string\n    \tthe template repository to use (default \"github/gitignore\")\n  -timeout duration\n    \tthe max duration for network requests (0 for no timeout) (default 30s)\n[\x1b[31mERROR\x1b[0m] need an action {\"filename\":\"base.go\",\"lineno\":488,\"seq\":1}\n",
 			2,
 		},
 	}
 
+	// requests are answered from the recorded responses
+	transport := http.DefaultTransport
+	http.DefaultTransport = replay(filepath.Join("..", "..", "testdata", "valid"))
+	defer func() { http.DefaultTransport = transport }()
+
+	system := state.SystemConfigFile
+	state.SystemConfigFile = os.DevNull
+	defer func() { state.SystemConfigFile = system }()
+
 	// cannot run these in parallel
 	for _, tt := range cases {
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
 			instance = &app.App{
-				Arguments:   tt.arguments,
-				Environment: tt.environment,
-				Context:     context.Background(),
-				Stdin:       strings.NewReader(tt.stdin),
-				Stdout:      new(bytes.Buffer),
-				Stderr:      new(bytes.Buffer),
+				Arguments: tt.arguments,
+				// keep the host's configuration out of the results
+				Environment: append([]string{
+					"HOME=",
+					"GIT_CONFIG_NOSYSTEM=1",
+					"GIT_CONFIG_GLOBAL=" + os.DevNull,
+				}, tt.environment...),
+				Context: context.Background(),
+				Stdin:   strings.NewReader(tt.stdin),
+				Stdout:  new(bytes.Buffer),
+				Stderr:  new(bytes.Buffer),
 				ExitHandler: func(code int) {
 					panic(code)
 				},
@@ -74,3 +103,21 @@ func TestMain(t *testing.T) {
 		})
 	}
 }
+
+// replay answers requests with the recorded response stored at the host and path of the URL below its directory,
+// or in the _index file of a directory.
+type replay string
+
+func (r replay) RoundTrip(req *http.Request) (*http.Response, error) {
+	name := filepath.Join(string(r), req.URL.Hostname(), filepath.FromSlash(path.Clean(req.URL.Path)))
+	if st, err := os.Stat(name); err == nil && st.IsDir() {
+		name = filepath.Join(name, "_index")
+	}
+
+	fp, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return http.ReadResponse(bufio.NewReader(fp), req)
+}
@@ -1,5 +1,11 @@
 package state
 
+import (
+	"flag"
+	"fmt"
+	"io"
+)
+
 type (
 	ExitStatus uint8
 
@@ -8,14 +14,244 @@ type (
 		Run() ExitStatus
 	}
 
-	dumpCommand State
-	listCommand State
+	// FlagCommand is implemented by commands that accept flags after the action.
+	FlagCommand interface {
+		Command
+		Flags(fs *flag.FlagSet)
+	}
+
+	// CommandSpec describes an action and its usage.
+	CommandSpec struct {
+		Name string
+		// Args describes the positional arguments, e.g. "{template}...".
+		Args string
+		// Summary is the one line description in the list of actions.
+		Summary string
+		// Help is the longer description printed by "help {action}".
+		Help string
+		// Complete is how the shell completes the arguments.
+		Complete Completion
+		// Hidden actions are not listed in the usage or completed.
+		Hidden bool
+		New    func(s *State) Command
+	}
+
+	helpCommand State
 )
 
-func (c *dumpCommand) GetName() string { return "dump" }
+// Commands are the available actions, in the order they are listed in the usage.
+var Commands = []*CommandSpec{
+	{
+		Name:     "dump",
+		Args:     "{template}...",
+		Summary:  "dumps the selected template(s) to STDOUT",
+		Help:     "Writes each template as a managed block, in the order given, without touching .gitignore.",
+		Complete: CompleteTemplates,
+		New:      func(s *State) Command { return (*dumpCommand)(s) },
+	},
+	{
+		Name:    "list",
+		Args:    "[{search}...]",
+		Summary: "lists the available templates, optionally filtered by the provided arguments",
+		Help: "A template is listed if its name or path contains any of the searches, ignoring case. " +
+			"Exits 1 if no template matches.\n\n" +
+			"-format table aligns the columns to fit the terminal when STDOUT is one. -format also accepts a Go " +
+			"template executed for each template, e.g. -format '{{.Name}}\\t{{.Size}}'; the fields are Name, " +
+			"Path, Size, SHA and Tags.",
+		Complete: CompleteTemplates,
+		New:      func(s *State) Command { return (*listCommand)(s) },
+	},
+	{
+		Name:    "show",
+		Args:    "{template}",
+		Summary: "prints a template's metadata, the last commit that changed it and its numbered content",
+		Help: "The template is looked up like the arguments of dump. The metadata includes its size, blob SHA and " +
+			"its address on the web; -format json prints the details and the unnumbered content as an object.",
+		Complete: CompleteTemplates,
+		New:      func(s *State) Command { return (*showCommand)(s) },
+	},
+	{
+		Name:    "auth",
+		Args:    "[status]",
+		Summary: "reports the authenticated user, token source, scopes and rate limits",
+		Help: "Tokens are looked up in GITHUB_TOKEN, GH_TOKEN, the gh CLI configuration, git credential helpers " +
+			"and ~/.netrc.",
+		Complete: CompleteWords,
+		New:      func(s *State) Command { return (*authCommand)(s) },
+	},
+	{
+		Name:    "check-ignore",
+		Args:    "{path}...",
+		Summary: "explains which rule and template block in .gitignore ignores each path",
+		Help: "Like git, the rules are read from core.excludesFile, .git/info/exclude and the .gitignore files of the " +
+			"directories leading to each path, taking the working directory as the top of the tree. " +
+			"Exits 0 if any path is ignored and 1 if none are, like git check-ignore.",
+		New: func(s *State) Command { return (*checkIgnoreCommand)(s) },
+	},
+	{
+		Name:     "lint",
+		Args:     "[{template}...]",
+		Summary:  "checks .gitignore, or the selected templates, for conflicting and redundant rules",
+		Help:     "Exits 1 if any errors are found.",
+		Complete: CompleteTemplates,
+		New:      func(s *State) Command { return (*lintCommand)(s) },
+	},
+	{
+		Name:    "update",
+		Args:    "[{template}...]",
+		Summary: "refreshes the managed blocks in .gitignore, adding blocks for any named templates",
+		Help: "Without templates, the manifest (.gitignore.toml or .gitignore.yaml) is used if there is one, " +
+			"otherwise every managed block is refreshed. Local edits to a block are merged with the upstream changes; " +
+			"if they conflict, nothing is written unless -conflict-markers is given. Exits 1 on conflicts. " +
+			"With -target exclude or global, the blocks are kept in .git/info/exclude or core.excludesFile instead; " +
+			"with dockerignore, npmignore, helmignore or gcloudignore, the rules are converted to that file's syntax " +
+			"and rules whose meaning differs are reported. With -recursive, the .gitignore of every project in the " +
+			"tree is updated and a summary of the files is printed.",
+		Complete: CompleteTemplates,
+		New:      func(s *State) Command { return (*updateCommand)(s) },
+	},
+	{
+		Name:     "diff",
+		Args:     "[{template}...]",
+		Summary:  "shows the changes update would make to .gitignore and the tracked files it would ignore",
+		Help:     "Exits 1 if update would change .gitignore.",
+		Complete: CompleteTemplates,
+		New:      func(s *State) Command { return (*diffCommand)(s) },
+	},
+	{
+		Name:    "changes",
+		Args:    "[{template}...]",
+		Summary: "lists the upstream commits and changes to the templates of managed blocks since they were fetched",
+		Help: "Without templates, every managed block from the template repository is compared. Commits are only " +
+			"listed if " + LockFile + " records the commit the blocks were fetched from.",
+		Complete: CompleteBlocks,
+		New:      func(s *State) Command { return (*changesCommand)(s) },
+	},
+	{
+		Name:    "detect",
+		Summary: "suggests templates based on marker files in the working tree",
+		Help:    "Exits 1 if no templates are suggested.",
+		New:     func(s *State) Command { return (*detectCommand)(s) },
+	},
+	{
+		Name:     "init",
+		Args:     "[{template}...]",
+		Summary:  "creates .gitignore and its lockfile from the detected and selected templates",
+		Help:     "Each detected template is confirmed on STDIN unless -yes is given.",
+		Complete: CompleteTemplates,
+		New:      func(s *State) Command { return (*initCommand)(s) },
+	},
+	{
+		Name:     "add",
+		Args:     "{template}...",
+		Summary:  "adds managed blocks for the selected templates to .gitignore",
+		Complete: CompleteTemplates,
+		New:      func(s *State) Command { return (*addCommand)(s) },
+	},
+	{
+		Name:     "remove",
+		Args:     "{template}...",
+		Summary:  "removes the managed blocks for the selected templates from .gitignore",
+		Complete: CompleteBlocks,
+		New:      func(s *State) Command { return (*removeCommand)(s) },
+	},
+	{
+		Name:    "config",
+		Args:    "show",
+		Summary: "shows the effective settings and where each was set",
+		Help: "Settings are read from " + SystemConfigFile + ", $XDG_CONFIG_HOME/update-gitignore/config, " +
+			ProjectConfigFile + " in the working directory and " + EnvPrefix + "* environment variables, " +
+			"in increasing precedence, as `name = value` lines. Action flags are named {action}.{flag}, " +
+			"e.g. dump.dedupe. Since " + ProjectConfigFile + " is usually checked in, it can't set the target " +
+			"flag, which can point outside the repository, or the force and yes flags, which answer for the user.",
+		Complete: CompleteWords,
+		New:      func(s *State) Command { return (*configCommand)(s) },
+	},
+	{
+		Name:     "completion",
+		Args:     "bash|zsh|fish",
+		Summary:  "prints the shell completion script, e.g. source <(update-gitignore completion bash)",
+		Complete: CompleteWords,
+		New:      func(s *State) Command { return (*completionCommand)(s) },
+	},
+	{
+		Name:    "__complete",
+		Args:    "-- {word}...",
+		Summary: "prints the completions of the last word",
+		Hidden:  true,
+		New:     func(s *State) Command { return (*completeCommand)(s) },
+	},
+	{
+		Name:     "help",
+		Args:     "[{action}]",
+		Summary:  "shows the usage of an action",
+		Complete: CompleteActions,
+		New:      func(s *State) Command { return (*helpCommand)(s) },
+	},
+}
 
-func (c *dumpCommand) Run() ExitStatus { return 0 }
+// LookupCommand returns the action with the name, or nil.
+func LookupCommand(name string) *CommandSpec {
+	for _, spec := range Commands {
+		if spec.Name == name {
+			return spec
+		}
+	}
+	return nil
+}
 
-func (c *listCommand) GetName() string { return "list" }
+// Usage writes the usage of the action, including its flags.
+func (spec *CommandSpec) Usage(w io.Writer, fs *flag.FlagSet) {
+	line := "usage: update-gitignore [{flags}] " + spec.Name
+	if hasFlags(fs) {
+		line += " [{" + spec.Name + " flags}]"
+	}
+	if spec.Args != "" {
+		line += " " + spec.Args
+	}
 
-func (c *listCommand) Run() ExitStatus { return 0 }
+	fmt.Fprintf(w, "%s\n\n%s\n", line, spec.Summary)
+	if spec.Help != "" {
+		fmt.Fprintf(w, "\n%s\n", spec.Help)
+	}
+
+	if hasFlags(fs) {
+		fmt.Fprintln(w, "\nFlags:")
+		out := fs.Output()
+		fs.SetOutput(w)
+		fs.PrintDefaults()
+		fs.SetOutput(out)
+	}
+}
+
+func hasFlags(fs *flag.FlagSet) bool {
+	var rv bool
+	fs.VisitAll(func(*flag.Flag) { rv = true })
+	return rv
+}
+
+func (c *helpCommand) GetName() string { return "help" }
+
+// Run prints the usage of the named action to STDOUT, or the general usage if no action is named.
+func (c *helpCommand) Run() ExitStatus {
+	s := (*State)(c)
+
+	switch len(s.templates) {
+	case 0:
+		s.flags.SetOutput(s.Stdout)
+		s.flags.Usage()
+		s.flags.SetOutput(s.Stderr)
+		return 0
+	case 1:
+		spec := LookupCommand(s.templates[0])
+		if spec == nil {
+			s.Logger().Errorf("unrecognized action %s", s.templates[0])
+			return 2
+		}
+		spec.Usage(s.Stdout, s.actionFlags[spec.Name])
+		return 0
+	default:
+		s.Logger().Error("help takes at most one action")
+		return 2
+	}
+}
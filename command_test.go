@@ -0,0 +1,107 @@
+package state
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHelpCommand_Run(t *testing.T) {
+	cases := []struct {
+		name     string
+		args     []string
+		stdout   string
+		exitcode ExitStatus
+	}{
+		{
+			"general",
+			[]string{"help"},
+			usageValue,
+			0,
+		},
+		{
+			"action with flags",
+			[]string{"help", "add"},
+			chain(
+				"usage: update-gitignore [{flags}] add [{add flags}] {template}...\n",
+				"\n",
+				"adds managed blocks for the selected templates to .gitignore\n",
+				"\n",
+				"Flags:\n",
+				usageLine("-after string", "the block after which to insert the templates (default sorted by name)"),
+				usageLine("-backup", "keep a copy of each changed file with a .bak suffix, timestamped if one exists"),
+				usageLine("-dry-run", "print the changes as a diff instead of writing them"),
+			),
+			0,
+		},
+		{
+			"action without flags",
+			[]string{"help", "check-ignore"},
+			chain(
+				"usage: update-gitignore [{flags}] check-ignore {path}...\n",
+				"\n",
+				"explains which rule and template block in .gitignore ignores each path\n",
+				"\n",
+				"Like git, the rules are read from core.excludesFile, .git/info/exclude and the .gitignore files of the ",
+				"directories leading to each path, taking the working directory as the top of the tree. ",
+				"Exits 0 if any path is ignored and 1 if none are, like git check-ignore.\n",
+			),
+			0,
+		},
+		{
+			"show",
+			[]string{"help", "show"},
+			chain(
+				"usage: update-gitignore [{flags}] show {template}\n",
+				"\n",
+				"prints a template's metadata, the last commit that changed it and its numbered content\n",
+				"\n",
+				"The template is looked up like the arguments of dump. The metadata includes its size, blob SHA and ",
+				"its address on the web; -format json prints the details and the unnumbered content as an object.\n",
+			),
+			0,
+		},
+		{
+			"unknown action",
+			[]string{"help", "nope"},
+			"",
+			2,
+		},
+		{
+			"too many",
+			[]string{"help", "add", "remove"},
+			"",
+			2,
+		},
+	}
+
+	t.Parallel()
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			s := newState(nil, "valid", tt.args...)
+			defer s.Logger().ShutdownLoggers()
+			require.NoError(t, s.ParseArguments())
+
+			cmd, err := s.Command()
+			require.NoError(t, err)
+			assert.Equal(t, "help", cmd.GetName())
+			assert.Equal(t, tt.exitcode, cmd.Run())
+			assert.Equal(t, tt.stdout, s.Stdout.(*bytes.Buffer).String())
+		})
+	}
+}
+
+func TestCommands(t *testing.T) {
+	s := newState(nil, "valid", "help")
+	defer s.Logger().ShutdownLoggers()
+
+	for _, spec := range Commands {
+		assert.Equal(t, spec, LookupCommand(spec.Name))
+		assert.Equal(t, spec.Name, spec.New(s).GetName())
+		assert.NotEmpty(t, spec.Summary, spec.Name)
+	}
+	assert.Nil(t, LookupCommand("nope"))
+}
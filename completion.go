@@ -0,0 +1,223 @@
+package state
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	// CompleteFiles leaves completion of the arguments to the shell's filename completion.
+	CompleteFiles Completion = iota
+	// CompleteTemplates completes template names from the cached catalog.
+	CompleteTemplates
+	// CompleteBlocks completes the names of the managed blocks in .gitignore.
+	CompleteBlocks
+	// CompleteActions completes action names.
+	CompleteActions
+	// CompleteWords completes the alternatives listed in the action's Args, e.g. "bash|zsh|fish".
+	CompleteWords
+)
+
+type (
+	// Completion is how the arguments of an action are completed.
+	Completion int
+
+	completionCommand State
+	completeCommand   State
+)
+
+var completionScripts = map[string]string{
+	"bash": `# bash completion for update-gitignore
+_update_gitignore() {
+	local IFS=$'\n'
+	COMPREPLY=($(update-gitignore __complete -- "${COMP_WORDS[@]:1:$COMP_CWORD}" 2>/dev/null))
+}
+complete -o default -F _update_gitignore update-gitignore
+`,
+	"zsh": `#compdef update-gitignore
+_update_gitignore() {
+	local -a candidates
+	candidates=("${(@f)$(update-gitignore __complete -- "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+	if [[ -n "${candidates[1]}" ]]; then
+		compadd -a candidates
+	else
+		_files
+	fi
+}
+compdef _update_gitignore update-gitignore
+`,
+	"fish": `# fish completion for update-gitignore
+complete -c update-gitignore -a '(update-gitignore __complete -- (commandline -opc)[2..-1] (commandline -ct) 2>/dev/null)'
+`,
+}
+
+func (c *completionCommand) GetName() string { return "completion" }
+
+// Run prints the completion script for the shell.
+func (c *completionCommand) Run() ExitStatus {
+	s := (*State)(c)
+
+	if len(s.templates) != 1 || completionScripts[s.templates[0]] == "" {
+		s.Logger().Error("usage: completion bash|zsh|fish")
+		return 2
+	}
+
+	if _, err := fmt.Fprint(s.Stdout, completionScripts[s.templates[0]]); err != nil {
+		s.Logger().Error(err.Error())
+		return 2
+	}
+
+	return 0
+}
+
+func (c *completeCommand) GetName() string { return "__complete" }
+
+// Run prints the candidates for the last of the words, which are the command line after the program name, one
+// per line. It never makes network requests.
+func (c *completeCommand) Run() ExitStatus {
+	s := (*State)(c)
+
+	for _, candidate := range s.Complete(s.templates) {
+		fmt.Fprintln(s.Stdout, candidate)
+	}
+
+	return 0
+}
+
+// Complete returns the sorted candidates for the last word of a partial command line.
+func (s *State) Complete(words []string) []string {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+
+	var (
+		word  = words[len(words)-1]
+		spec  *CommandSpec
+		flags = s.flags
+		// value is the flag whose value is being completed
+		value *flag.Flag
+		nargs int
+	)
+
+	for _, w := range words[:len(words)-1] {
+		switch {
+		case value != nil:
+			value = nil
+		case strings.HasPrefix(w, "-") && w != "-" && w != "--":
+			name := strings.TrimLeft(w, "-")
+			if f := flags.Lookup(name); f != nil && !strings.Contains(name, "=") && !isBoolFlag(f) {
+				value = f
+			}
+		case spec == nil:
+			if spec = LookupCommand(w); spec == nil {
+				return nil
+			}
+			flags = s.actionFlags[spec.Name]
+		default:
+			nargs++
+		}
+	}
+
+	var candidates []string
+	switch {
+	case value != nil:
+		candidates = s.completeFlag(value)
+	case strings.HasPrefix(word, "-"):
+		flags.VisitAll(func(f *flag.Flag) { candidates = append(candidates, "-"+f.Name) })
+	case spec == nil:
+		for _, spec := range Commands {
+			if !spec.Hidden {
+				candidates = append(candidates, spec.Name)
+			}
+		}
+	default:
+		candidates = s.completeArgs(spec, nargs)
+	}
+
+	var rv []string
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, word) {
+			rv = append(rv, candidate)
+		}
+	}
+	sort.Strings(rv)
+
+	return rv
+}
+
+func (s *State) completeFlag(f *flag.Flag) []string {
+	switch f.Name {
+	case "format":
+		return Formats
+	case "after":
+		return s.blockNames()
+	case "target":
+		return Targets
+	default:
+		return nil
+	}
+}
+
+func (s *State) completeArgs(spec *CommandSpec, nargs int) []string {
+	switch spec.Complete {
+	case CompleteTemplates:
+		cat := s.CachedCatalog(s.repo, DefaultRef)
+		if cat == nil {
+			return nil
+		}
+		var rv []string
+		for _, t := range cat.Templates {
+			rv = append(rv, t.Name)
+			if len(t.Tags) > 0 {
+				rv = append(rv, strings.TrimSuffix(t.Path, Suffix))
+			}
+		}
+		return rv
+	case CompleteBlocks:
+		return s.blockNames()
+	case CompleteActions:
+		if nargs > 0 {
+			return nil
+		}
+		var rv []string
+		for _, spec := range Commands {
+			if !spec.Hidden {
+				rv = append(rv, spec.Name)
+			}
+		}
+		return rv
+	case CompleteWords:
+		if nargs > 0 {
+			return nil
+		}
+		return strings.Split(strings.Trim(spec.Args, "[]"), "|")
+	default:
+		return nil
+	}
+}
+
+// blockNames returns the names of the managed blocks in the local gitignore file.
+func (s *State) blockNames() []string {
+	g, err := s.ReadGitignore(GitignoreFile)
+	if err != nil {
+		return nil
+	}
+
+	blocks, err := g.Blocks()
+	if err != nil {
+		return nil
+	}
+
+	rv := make([]string, len(blocks))
+	for idx, b := range blocks {
+		rv[idx] = b.Name
+	}
+	return rv
+}
+
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
@@ -0,0 +1,93 @@
+package state
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestState_Complete(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	writeFile(t, filepath.Join(dir, ".gitignore"), chain(block("Go", "*.exe\n"), block("Node", "node_modules/\n")))
+	env := []string{"XDG_CACHE_HOME=" + filepath.Join(dir, "cache")}
+
+	// fetching the catalog caches it
+	s := newState(env, "valid", "-C", dir, "help")
+	defer s.Logger().ShutdownLoggers()
+	require.NoError(t, s.ParseArguments())
+	assert.Nil(t, s.CachedCatalog("github/gitignore", DefaultRef))
+	_, err := s.Catalog()
+	require.NoError(t, err)
+	require.NotNil(t, s.CachedCatalog("github/gitignore", DefaultRef))
+
+	cases := []struct {
+		name   string
+		words  []string
+		output []string
+	}{
+		{"nothing", nil, []string{"add", "auth", "changes", "check-ignore", "completion", "config", "detect", "diff", "dump", "help", "init", "lint", "list", "remove", "show", "update"}},
+		{"action", []string{"d"}, []string{"detect", "diff", "dump"}},
+		{"global flags", []string{"-"}, []string{"-C", "-debug", "-format", "-repo", "-timeout"}},
+		{"flag value", []string{"-format", ""}, []string{"json", "ndjson", "table", "text"}},
+		{"after flag value", []string{"-repo", "github/gitignore", "add", "-after", "N"}, []string{"Node"}},
+		{"action flags", []string{"dump", "-"}, []string{"-dedupe"}},
+		{"templates", []string{"dump", "Go", "VisualStudio"}, []string{"VisualStudio", "VisualStudioCode"}},
+		{"template paths", []string{"-debug", "add", "Global/mac"}, []string{"Global/macOS"}},
+		{"blocks", []string{"remove", ""}, []string{"Go", "Node"}},
+		{"actions", []string{"help", "co"}, []string{"completion", "config"}},
+		{"one action", []string{"help", "dump", ""}, nil},
+		{"words", []string{"completion", ""}, []string{"bash", "fish", "zsh"}},
+		{"optional words", []string{"auth", ""}, []string{"status"}},
+		{"files", []string{"check-ignore", ""}, nil},
+		{"unknown action", []string{"nope", ""}, nil},
+	}
+
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.output, s.Complete(tt.words))
+		})
+	}
+
+	// without a cached catalog there is nothing to complete
+	s = newState(nil, "valid", "-C", dir, "help")
+	defer s.Logger().ShutdownLoggers()
+	require.NoError(t, s.ParseArguments())
+	assert.Nil(t, s.Complete([]string{"dump", "Go"}))
+}
+
+func TestCompletionCommand_Run(t *testing.T) {
+	cases := []struct {
+		name     string
+		args     []string
+		stdout   string
+		exitcode ExitStatus
+	}{
+		{"bash", []string{"completion", "bash"}, "complete -o default -F _update_gitignore update-gitignore\n", 0},
+		{"zsh", []string{"completion", "zsh"}, "compdef _update_gitignore update-gitignore\n", 0},
+		{"fish", []string{"completion", "fish"}, "complete -c update-gitignore", 0},
+		{"unknown", []string{"completion", "tcsh"}, "", 2},
+		{"complete", []string{"__complete", "--", "-format", "j"}, "json\n", 0},
+	}
+
+	t.Parallel()
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			s := newState(nil, "valid", tt.args...)
+			defer s.Logger().ShutdownLoggers()
+			require.NoError(t, s.ParseArguments())
+
+			cmd, err := s.Command()
+			require.NoError(t, err)
+			assert.Equal(t, tt.args[0], cmd.GetName())
+			assert.Equal(t, tt.exitcode, cmd.Run())
+			assert.Contains(t, s.Stdout.(*bytes.Buffer).String(), tt.stdout)
+		})
+	}
+}
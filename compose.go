@@ -0,0 +1,135 @@
+package state
+
+import (
+	"strings"
+)
+
+// Section is the content of a template to be written as a managed block.
+type Section struct {
+	Template *Template
+	Repo     string
+	Content  *Gitignore
+}
+
+// Block returns the block markers describing the section.
+func (sec *Section) Block() *Block {
+	return &Block{
+		Name: sec.Template.Name,
+		Repo: sec.Repo,
+		Path: sec.Template.Path,
+		SHA:  sec.Template.SHA,
+	}
+}
+
+// Lines renders the section as a managed block, including the markers.
+func (sec *Section) Lines() []string {
+	b := sec.Block()
+	rv := []string{b.Header()}
+	for _, line := range sec.Content.Lines {
+		rv = append(rv, line.Text)
+	}
+	return append(rv, b.Footer())
+}
+
+// Sections downloads and parses the contents of each template in the catalog.
+func (s *State) Sections(cat *Catalog, templates []*Template) ([]*Section, error) {
+	cl, err := s.clientFor(cat.Repo)
+	if err != nil {
+		return nil, err
+	}
+
+	rv := make([]*Section, len(templates))
+	for idx, t := range templates {
+		text, err := cl.GetTemplate(t)
+		if err != nil {
+			return nil, err
+		}
+
+		rv[idx] = &Section{
+			Template: t,
+			Repo:     cat.Repo,
+			Content:  ParseGitignoreString(text),
+		}
+	}
+
+	return rv, nil
+}
+
+// Compose joins the sections into a single gitignore file of managed blocks separated by blank lines.
+func Compose(sections []*Section) *Gitignore {
+	var lines []string
+	for idx, sec := range sections {
+		if idx > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, sec.Lines()...)
+	}
+
+	if len(lines) == 0 {
+		return new(Gitignore)
+	}
+
+	return ParseGitignoreString(strings.Join(lines, "\n") + "\n")
+}
+
+// Dedupe removes pattern lines that exactly or semantically duplicate a pattern from an earlier section. Comments,
+// blank lines and the first occurrence of each pattern are kept. A duplicate is kept if a negated pattern appears
+// between it and the first occurrence since removing it could change which paths are ignored. Returns the number
+// of lines removed from each section, keyed by template name.
+func Dedupe(sections []*Section) map[string]int {
+	var (
+		dropped = make(map[string]int)
+		// seen maps the canonical form of each pattern to the index of the section containing its first occurrence
+		seen = make(map[string]int)
+		// negations counts the negated patterns seen so far
+		negations int
+		// since records the value of negations when each pattern was first seen
+		since = make(map[string]int)
+	)
+
+	for idx, sec := range sections {
+		current := make(map[string]bool)
+		kept := sec.Content.Lines[:0:0]
+		for _, line := range sec.Content.Lines {
+			if line.Kind != PatternLine {
+				kept = append(kept, line)
+				continue
+			}
+
+			key := line.Pattern.Canonical()
+			if first, ok := seen[key]; ok && first < idx && since[key] == negations {
+				dropped[sec.Template.Name]++
+				continue
+			}
+
+			if !current[key] {
+				current[key] = true
+				seen[key] = idx
+				since[key] = negations
+			}
+			if line.Pattern.Negated {
+				negations++
+			}
+			kept = append(kept, line)
+		}
+
+		sec.Content = &Gitignore{Lines: kept, NoFinalNewline: sec.Content.NoFinalNewline}
+	}
+
+	return dropped
+}
+
+// Canonical returns a normalized form of the pattern. Patterns with the same canonical form match the same paths.
+func (p *Pattern) Canonical() string {
+	q := *p
+	// collapse runs of "**/" which are equivalent to a single "**/"
+	for strings.Contains(q.Glob, "**/**/") {
+		q.Glob = strings.Replace(q.Glob, "**/**/", "**/", -1)
+	}
+	// a leading "**/" matches in any directory, the same as an unanchored pattern
+	if strings.HasPrefix(q.Glob, "**/") && !strings.Contains(q.Glob[3:], "/") {
+		q.Glob = q.Glob[3:]
+		q.Anchored = false
+	}
+	return q.String()
+}
@@ -0,0 +1,78 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newSection(name, text string) *Section {
+	return &Section{
+		Template: &Template{Name: name, Path: name + Suffix},
+		Repo:     "github/gitignore",
+		Content:  ParseGitignoreString(text),
+	}
+}
+
+func TestPattern_Canonical(t *testing.T) {
+	cases := []struct {
+		text      string
+		canonical string
+	}{
+		{"*.log", "*.log"},
+		{"*.log  ", "*.log"},
+		{"**/*.log", "*.log"},
+		{"/**/*.log", "*.log"},
+		{"**/**/logs/", "logs/"},
+		{"/build", "/build"},
+		{"/doc/*.txt", "doc/*.txt"},
+		{"a/**/**/b", "a/**/b"},
+		{"**/a/b", "**/a/b"},
+		{"!keep", "!keep"},
+	}
+
+	t.Parallel()
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.text, func(t *testing.T) {
+			assert.Equal(t, tt.canonical, ParsePattern(tt.text).Canonical())
+		})
+	}
+}
+
+func TestDedupe(t *testing.T) {
+	sections := []*Section{
+		newSection("Go", "# Binaries\n*.exe\n*.log\n*.log\n.DS_Store\n"),
+		newSection("Node", "# Logs\n*.log\n**/.DS_Store\nnode_modules/\n"),
+		newSection("Python", "!keep.log\n*.exe\n"),
+		newSection("Other", "*.log\nnode_modules/\n"),
+	}
+
+	dropped := Dedupe(sections)
+	assert.Equal(t, map[string]int{"Node": 2}, dropped)
+
+	assert.Equal(t, "# Binaries\n*.exe\n*.log\n*.log\n.DS_Store\n", sections[0].Content.String())
+	assert.Equal(t, "# Logs\nnode_modules/\n", sections[1].Content.String())
+	// duplicates following a negation are kept since the negation may have re-included paths they match
+	assert.Equal(t, "!keep.log\n*.exe\n", sections[2].Content.String())
+	assert.Equal(t, "*.log\nnode_modules/\n", sections[3].Content.String())
+}
+
+func TestCompose(t *testing.T) {
+	sections := []*Section{
+		newSection("Go", "*.exe\n"),
+		newSection("Node", "node_modules/\n"),
+	}
+	sections[0].Template.SHA = "abc123"
+
+	assert.Equal(t, chain(
+		"# BEGIN update-gitignore: Go repo=github/gitignore path=Go.gitignore sha=abc123\n",
+		"*.exe\n",
+		"# END update-gitignore: Go\n",
+		"\n",
+		"# BEGIN update-gitignore: Node repo=github/gitignore path=Node.gitignore\n",
+		"node_modules/\n",
+		"# END update-gitignore: Node\n",
+	), Compose(sections).String())
+	assert.Equal(t, "", Compose(nil).String())
+}
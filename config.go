@@ -0,0 +1,221 @@
+package state
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// ProjectConfigFile is the name of the configuration file read from the working directory.
+	ProjectConfigFile = ".update-gitignore.conf"
+	// EnvPrefix prefixes the environment variables that set flags, e.g. UPDATE_GITIGNORE_REPO sets -repo.
+	EnvPrefix = "UPDATE_GITIGNORE_"
+)
+
+// SystemConfigFile is the configuration file shared by every user of the system.
+var SystemConfigFile = "/etc/update-gitignore/config"
+
+// projectDeniedFlags are the flags a project configuration file can't set. It is usually checked in, so a clone
+// shouldn't be able to write outside the repository (-target global) or answer the confirmations meant for the user.
+var projectDeniedFlags = []string{"target", "force", "yes"}
+
+type (
+	configCommand State
+
+	// Setting is the effective value of a flag and where it was set.
+	Setting struct {
+		Name   string `json:"name"`
+		Value  string `json:"value"`
+		Origin string `json:"origin"`
+	}
+
+	configEntry struct {
+		Key   string
+		Value string
+		Line  int
+	}
+)
+
+func (c *configCommand) GetName() string { return "config" }
+
+// Run prints the effective settings and their origins for `config show`.
+func (c *configCommand) Run() ExitStatus {
+	s := (*State)(c)
+	logger := s.Logger()
+
+	if len(s.templates) != 1 || s.templates[0] != "show" {
+		logger.Error("usage: config show")
+		return 2
+	}
+
+	var err error
+	if s.format == "json" {
+		enc := json.NewEncoder(s.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(s.settings)
+	} else {
+		for _, setting := range s.settings {
+			if _, err = fmt.Fprintf(s.Stdout, "%s = %s\t# %s\n", setting.Name, setting.Value, setting.Origin); err != nil {
+				break
+			}
+		}
+	}
+
+	if err != nil {
+		logger.Error(err.Error())
+		return 2
+	}
+
+	return 0
+}
+
+// Settings returns the effective value of every flag and where it was set.
+func (s *State) Settings() []Setting {
+	return s.settings
+}
+
+// configFiles returns the configuration files in order of increasing precedence.
+func (s *State) configFiles() []string {
+	files := []string{SystemConfigFile}
+	if dir := s.configDir(); dir != "" {
+		files = append(files, filepath.Join(dir, "update-gitignore", "config"))
+	}
+	return append(files, s.Path(ProjectConfigFile))
+}
+
+// applyConfig sets the flags not given on the command line from the configuration files and then the environment,
+// recording the origin of each flag's value. Global flags are named as on the command line and action flags are
+// prefixed with the action, e.g. dump.dedupe.
+func (s *State) applyConfig(global *flag.FlagSet, actions map[string]*flag.FlagSet) error {
+	var (
+		keys    []string
+		flags   = make(map[string]*flag.Flag)
+		origins = make(map[string]string)
+	)
+
+	declare := func(prefix string, fs *flag.FlagSet) {
+		fs.VisitAll(func(f *flag.Flag) {
+			if f.Name != "C" {
+				keys = append(keys, prefix+f.Name)
+				flags[prefix+f.Name] = f
+			}
+		})
+		fs.Visit(func(f *flag.Flag) { origins[prefix+f.Name] = "command line" })
+	}
+
+	declare("", global)
+	for _, spec := range Commands {
+		declare(spec.Name+".", actions[spec.Name])
+	}
+
+	set := func(key, value, origin string) error {
+		f, ok := flags[key]
+		if !ok {
+			return fmt.Errorf("%s: unknown setting %s", origin, key)
+		}
+		if origins[key] == "command line" {
+			return nil
+		}
+		if err := f.Value.Set(value); err != nil {
+			return fmt.Errorf("%s: invalid value %q for %s: %v", origin, value, key, err)
+		}
+		origins[key] = origin
+		return nil
+	}
+
+	project := s.Path(ProjectConfigFile)
+	for _, name := range s.configFiles() {
+		entries, err := readConfig(name)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			origin := fmt.Sprintf("%s:%d", name, e.Line)
+			if name == project && contains(projectDeniedFlags, e.Key[strings.LastIndex(e.Key, ".")+1:]) {
+				return fmt.Errorf("%s: %s can't be set in %s; use the command line, the environment or a user configuration file", origin, e.Key, ProjectConfigFile)
+			}
+			if err := set(e.Key, e.Value, origin); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, key := range keys {
+		env := EnvPrefix + strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(key))
+		if value, ok := s.LookupEnv(env); ok {
+			if err := set(key, value, "environment "+env); err != nil {
+				return err
+			}
+		}
+	}
+
+	s.settings = []Setting{{Name: "C", Value: global.Lookup("C").Value.String(), Origin: "default"}}
+	if origin, ok := origins["C"]; ok {
+		s.settings[0].Origin = origin
+	}
+	for _, key := range keys {
+		origin, ok := origins[key]
+		if !ok {
+			origin = "default"
+		}
+		s.settings = append(s.settings, Setting{Name: key, Value: flags[key].Value.String(), Origin: origin})
+	}
+
+	return nil
+}
+
+// readConfig reads a configuration file. A missing file has no entries.
+func readConfig(name string) ([]configEntry, error) {
+	fp, err := os.Open(name)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer fp.Close()
+
+	entries, err := parseConfig(fp)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+
+	return entries, nil
+}
+
+// parseConfig parses `name = value` lines. Blank lines and lines starting with # are ignored, and a leading dash on
+// the name is optional so flags can be copied from the command line.
+func parseConfig(r io.Reader) ([]configEntry, error) {
+	var (
+		entries []configEntry
+		scanner = bufio.NewScanner(r)
+		number  int
+	)
+
+	for scanner.Scan() {
+		number++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("line %d: expected name = value", number)
+		}
+
+		entries = append(entries, configEntry{
+			Key:   strings.TrimLeft(strings.TrimSpace(kv[0]), "-"),
+			Value: strings.TrimSpace(kv[1]),
+			Line:  number,
+		})
+	}
+
+	return entries, scanner.Err()
+}
@@ -0,0 +1,166 @@
+package state
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConfig(t *testing.T) {
+	entries, err := parseConfig(bytes.NewBufferString(chain(
+		"# comment\n",
+		"\n",
+		"repo = acme/gitignore\n",
+		"  -timeout=5s  \n",
+		"after =\n",
+	)))
+	require.NoError(t, err)
+	assert.Equal(t, []configEntry{
+		{Key: "repo", Value: "acme/gitignore", Line: 3},
+		{Key: "timeout", Value: "5s", Line: 4},
+		{Key: "after", Value: "", Line: 5},
+	}, entries)
+
+	_, err = parseConfig(bytes.NewBufferString("debug\n"))
+	assert.EqualError(t, err, "line 1: expected name = value")
+}
+
+func TestConfigCommand_Run(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	xdg := filepath.Join(dir, "xdg")
+	require.NoError(t, os.MkdirAll(filepath.Join(xdg, "update-gitignore"), 0755))
+	user := filepath.Join(xdg, "update-gitignore", "config")
+	writeFile(t, user, "repo = user/templates\ntimeout = 10s\nformat = json\n")
+	project := filepath.Join(dir, ProjectConfigFile)
+	writeFile(t, project, "# project defaults\n-timeout = 5s\ndump.dedupe = true\n")
+
+	env := []string{"XDG_CONFIG_HOME=" + xdg, "UPDATE_GITIGNORE_DEBUG=true", "UPDATE_GITIGNORE_INIT_FORCE=false"}
+	s := newState(env, "valid", "-C", dir, "-format", "text", "config", "show")
+	defer s.Logger().ShutdownLoggers()
+	require.NoError(t, s.ParseArguments())
+
+	assert.Equal(t, "user/templates", s.Repo())
+	assert.Equal(t, "5s", s.Timeout().String())
+	assert.True(t, s.Debug())
+	// action settings only apply to their action
+	assert.False(t, s.Dedupe())
+	assert.Equal(t, "text", s.Format())
+
+	cmd, err := s.Command()
+	require.NoError(t, err)
+	assert.Equal(t, "config", cmd.GetName())
+	assert.Equal(t, ExitStatus(0), cmd.Run())
+	assert.Equal(t, chain(
+		"C = "+dir+"\t# command line\n",
+		"debug = true\t# environment UPDATE_GITIGNORE_DEBUG\n",
+		"format = text\t# command line\n",
+		"repo = user/templates\t# "+user+":1\n",
+		"timeout = 5s\t# "+project+":2\n",
+		"dump.dedupe = true\t# "+project+":3\n",
+		"update.backup = false\t# default\n",
+		"update.conflict-markers = false\t# default\n",
+		"update.dry-run = false\t# default\n",
+		"update.recursive = false\t# default\n",
+		"update.target = gitignore\t# default\n",
+		"diff.target = gitignore\t# default\n",
+		"detect.names = false\t# default\n",
+		"init.backup = false\t# default\n",
+		"init.dry-run = false\t# default\n",
+		"init.force = false\t# environment UPDATE_GITIGNORE_INIT_FORCE\n",
+		"init.yes = false\t# default\n",
+		"add.after = \t# default\n",
+		"add.backup = false\t# default\n",
+		"add.dry-run = false\t# default\n",
+		"remove.backup = false\t# default\n",
+		"remove.dry-run = false\t# default\n",
+	), s.Stdout.(*bytes.Buffer).String())
+}
+
+func TestState_ParseArguments_Config(t *testing.T) {
+	cases := []struct {
+		name    string
+		env     []string
+		project string
+		err     string
+	}{
+		{"unknown setting", nil, "colour = red\n", ProjectConfigFile + ":1: unknown setting colour"},
+		{"directory", nil, "C = /tmp\n", ProjectConfigFile + ":1: unknown setting C"},
+		{"invalid value", nil, "\ntimeout = soon\n", ProjectConfigFile + ":2: invalid value \"soon\" for timeout: parse error"},
+		{"malformed", nil, "debug\n", ProjectConfigFile + ": line 1: expected name = value"},
+		{"environment", []string{"UPDATE_GITIGNORE_DUMP_DEDUPE=maybe"}, "", "environment UPDATE_GITIGNORE_DUMP_DEDUPE: invalid value \"maybe\" for dump.dedupe: parse error"},
+		{"unprefixed action flag", nil, "dedupe = true\n", ProjectConfigFile + ":1: unknown setting dedupe"},
+		{"project target", nil, "# shared\nupdate.target = global\n", ProjectConfigFile + ":2: update.target can't be set in " + ProjectConfigFile},
+		{"project yes", nil, "init.yes = true\n", ProjectConfigFile + ":1: init.yes can't be set in " + ProjectConfigFile},
+	}
+
+	t.Parallel()
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			dir, cleanup := tempDir(t)
+			defer cleanup()
+			writeFile(t, filepath.Join(dir, ProjectConfigFile), tt.project)
+
+			s := newState(tt.env, "valid", "-C", dir, "config", "show")
+			defer s.Logger().ShutdownLoggers()
+			err := s.ParseArguments()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
+
+func TestState_ParseArguments_ActionConfig(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	writeFile(t, filepath.Join(dir, ProjectConfigFile), "repo = acme/gitignore\ndump.dedupe = true\nadd.after = Go\n")
+
+	s := newState(nil, "valid", "-C", dir, "dump", "Go")
+	defer s.Logger().ShutdownLoggers()
+	require.NoError(t, s.ParseArguments())
+	assert.Equal(t, "acme/gitignore", s.Repo())
+	assert.True(t, s.Dedupe())
+	assert.Equal(t, "", s.After())
+}
+
+func TestState_ParseArguments_SystemConfig(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	system := filepath.Join(dir, "system")
+	writeFile(t, system, "repo = acme/gitignore\n")
+
+	// not parallel, so no other test is reading it
+	defer func(name string) { SystemConfigFile = name }(SystemConfigFile)
+	SystemConfigFile = system
+
+	s := newState(nil, "valid", "-C", dir, "config", "show")
+	defer s.Logger().ShutdownLoggers()
+	require.NoError(t, s.ParseArguments())
+	assert.Equal(t, "acme/gitignore", s.Repo())
+	assert.Contains(t, s.Settings(), Setting{Name: "repo", Value: "acme/gitignore", Origin: system + ":1"})
+}
+
+func TestState_ParseArguments_SharedActionFlags(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	// add, init and remove all declare -dry-run; only the running action's setting applies
+	env := []string{"UPDATE_GITIGNORE_ADD_DRY_RUN=true", "UPDATE_GITIGNORE_INIT_DRY_RUN=true"}
+	s := newState(env, "valid", "-C", dir, "remove", "Go")
+	defer s.Logger().ShutdownLoggers()
+	require.NoError(t, s.ParseArguments())
+	assert.False(t, s.dryRun)
+
+	s = newState(env, "valid", "-C", dir, "add", "Go")
+	defer s.Logger().ShutdownLoggers()
+	require.NoError(t, s.ParseArguments())
+	assert.True(t, s.dryRun)
+}
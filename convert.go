@@ -0,0 +1,163 @@
+package state
+
+import (
+	"fmt"
+	"strings"
+)
+
+type (
+	// Conversion rewrites gitignore rules into the syntax of another ignore file.
+	Conversion struct {
+		// File is the name of the ignore file, relative to the working directory.
+		File string
+		// rewrite returns the text of the line in the ignore file and, if its meaning differs from the gitignore
+		// rule, notes explaining how.
+		rewrite func(line *Line) (string, []string)
+	}
+
+	// ConversionNote flags a rule whose meaning differs in the converted file.
+	ConversionNote struct {
+		Line int
+		Rule string
+		Note string
+	}
+)
+
+// npmAlwaysIncluded are files npm packs regardless of .npmignore.
+var npmAlwaysIncluded = []string{"package.json", "README", "README.md", "LICENSE", "LICENSE.md", "LICENCE", "LICENCE.md"}
+
+// Conversions are the targets written in the syntax of another ignore file, keyed by target.
+var Conversions = map[string]*Conversion{
+	"dockerignore": {File: ".dockerignore", rewrite: dockerignoreRule},
+	"npmignore":    {File: ".npmignore", rewrite: npmignoreRule},
+	"helmignore":   {File: ".helmignore", rewrite: helmignoreRule},
+	"gcloudignore": {File: ".gcloudignore", rewrite: gcloudignoreRule},
+}
+
+// Convert rewrites the lines of g, returning the converted file and notes for the rules whose meaning differs.
+func (c *Conversion) Convert(g *Gitignore) (*Gitignore, []ConversionNote) {
+	var (
+		lines []string
+		notes []ConversionNote
+	)
+
+	for _, line := range g.Lines {
+		text, lineNotes := c.rewrite(line)
+		for _, note := range lineNotes {
+			notes = append(notes, ConversionNote{Line: line.Number, Rule: line.Text, Note: note})
+		}
+		lines = append(lines, text)
+	}
+
+	if len(lines) == 0 {
+		return new(Gitignore), notes
+	}
+
+	return ParseGitignoreString(strings.Join(lines, "\n") + "\n"), notes
+}
+
+// dockerignoreRule rewrites a rule for .dockerignore, where patterns are matched from the root of the build
+// context and a trailing slash is ignored.
+func dockerignoreRule(line *Line) (string, []string) {
+	if line.Kind != PatternLine {
+		return line.Text, nil
+	}
+
+	var (
+		p     = line.Pattern
+		text  = p.Glob
+		notes []string
+	)
+	if !p.Anchored {
+		text = "**/" + text
+		notes = append(notes, "rules without a slash only match at the root of the build context; prefixed with **/")
+	}
+	if p.DirOnly {
+		notes = append(notes, "a trailing / is ignored, so the rule also matches files")
+	}
+	if p.Negated {
+		text = "!" + text
+		notes = append(notes, "! re-includes files even inside excluded directories, unlike git")
+	}
+
+	return text, notes
+}
+
+// npmignoreRule keeps the rule, which npm reads with gitignore semantics, flagging rules for files npm always packs.
+func npmignoreRule(line *Line) (string, []string) {
+	if line.Kind != PatternLine || line.Pattern.Negated {
+		return line.Text, nil
+	}
+
+	rule := &Rule{Line: line}
+	for _, name := range npmAlwaysIncluded {
+		if rule.Matches(name, false) {
+			return line.Text, []string{"npm always packs package.json, README and LICENSE; the rule has no effect on them"}
+		}
+	}
+
+	return line.Text, nil
+}
+
+// helmignoreRule comments out rules using **, which helm rejects.
+func helmignoreRule(line *Line) (string, []string) {
+	if line.Kind != PatternLine || !strings.Contains(line.Pattern.Glob, "**") {
+		return line.Text, nil
+	}
+
+	return "# " + line.Text, []string{"** is not supported by helm; commented out"}
+}
+
+// gcloudignoreRule escapes comments that gcloud would read as an #!include directive.
+func gcloudignoreRule(line *Line) (string, []string) {
+	if line.Kind != CommentLine || !strings.HasPrefix(line.Text, "#!include:") {
+		return line.Text, nil
+	}
+
+	return "# " + line.Text[1:], []string{"#!include: is a directive in .gcloudignore; the comment is escaped"}
+}
+
+// convertSections converts the content of each section for the conversion, returning the notes of each block with
+// rules whose meaning differs by name.
+func convertSections(c *Conversion, sections []*Section) ([]*Section, map[string][]ConversionNote) {
+	var (
+		rv    = make([]*Section, len(sections))
+		notes = make(map[string][]ConversionNote)
+	)
+
+	for idx, sec := range sections {
+		content, blockNotes := c.Convert(sec.Content)
+		rv[idx] = &Section{Template: sec.Template, Repo: sec.Repo, Content: content}
+		if len(blockNotes) > 0 {
+			notes[sec.Template.Name] = blockNotes
+		}
+	}
+
+	return rv, notes
+}
+
+// conversionWarnings describes the rules of a block whose meaning differs in the converted file, one warning for
+// each way in which they differ.
+func conversionWarnings(file, block string, notes []ConversionNote) []string {
+	var (
+		order  []string
+		byNote = make(map[string][]ConversionNote)
+	)
+	for _, n := range notes {
+		if _, ok := byNote[n.Note]; !ok {
+			order = append(order, n.Note)
+		}
+		byNote[n.Note] = append(byNote[n.Note], n)
+	}
+
+	rv := make([]string, len(order))
+	for idx, note := range order {
+		first, rules := byNote[note][0], "rule differs"
+		if len(byNote[note]) > 1 {
+			rules = "rules differ"
+		}
+		rv[idx] = fmt.Sprintf("%s: %s: %d %s, e.g. %q (line %d): %s", file, block, len(byNote[note]), rules, first.Rule, first.Line, note)
+	}
+
+	return rv
+}
@@ -0,0 +1,152 @@
+package state
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConversion_Convert(t *testing.T) {
+	cases := []struct {
+		name   string
+		target string
+		input  string
+		output string
+		notes  []ConversionNote
+	}{
+		{
+			"dockerignore",
+			"dockerignore",
+			"# build\n/bin\n*.log\nlogs/\n!keep.log\ndocs/*.md\n",
+			"# build\nbin\n**/*.log\n**/logs\n!**/keep.log\ndocs/*.md\n",
+			[]ConversionNote{
+				{3, "*.log", "rules without a slash only match at the root of the build context; prefixed with **/"},
+				{4, "logs/", "rules without a slash only match at the root of the build context; prefixed with **/"},
+				{4, "logs/", "a trailing / is ignored, so the rule also matches files"},
+				{5, "!keep.log", "rules without a slash only match at the root of the build context; prefixed with **/"},
+				{5, "!keep.log", "! re-includes files even inside excluded directories, unlike git"},
+			},
+		},
+		{
+			"npmignore",
+			"npmignore",
+			"*.md\n!README.md\nnode_modules/\n",
+			"*.md\n!README.md\nnode_modules/\n",
+			[]ConversionNote{
+				{1, "*.md", "npm always packs package.json, README and LICENSE; the rule has no effect on them"},
+			},
+		},
+		{
+			"helmignore",
+			"helmignore",
+			"*.tmp\n**/.terraform/*\n.idea/\n",
+			"*.tmp\n# **/.terraform/*\n.idea/\n",
+			[]ConversionNote{
+				{2, "**/.terraform/*", "** is not supported by helm; commented out"},
+			},
+		},
+		{
+			"gcloudignore",
+			"gcloudignore",
+			"#!include:.gitignore\n# comment\n*.pyc\n",
+			"# !include:.gitignore\n# comment\n*.pyc\n",
+			[]ConversionNote{
+				{1, "#!include:.gitignore", "#!include: is a directive in .gcloudignore; the comment is escaped"},
+			},
+		},
+		{
+			"empty",
+			"dockerignore",
+			"",
+			"",
+			nil,
+		},
+	}
+
+	t.Parallel()
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			g, notes := Conversions[tt.target].Convert(ParseGitignoreString(tt.input))
+			assert.Equal(t, tt.output, g.String())
+			assert.Equal(t, tt.notes, notes)
+		})
+	}
+}
+
+func TestUpdateCommand_Conversion(t *testing.T) {
+	t.Parallel()
+
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	s := newState(nil, "valid", "-C", dir, "update", "-target", "dockerignore", "Global/macOS")
+	require.NoError(t, s.ParseArguments())
+
+	cmd, err := s.Command()
+	require.NoError(t, err)
+	assert.Equal(t, ExitStatus(0), cmd.Run())
+	require.NoError(t, s.Logger().ShutdownLoggers())
+
+	stderr := s.Stderr.(*bytes.Buffer).String()
+	assert.Contains(t, stderr, `.dockerignore: macOS: 17 rules differ, e.g. ".DS_Store" (line 2): rules without a slash only match at the root of the build context; prefixed with **/`)
+
+	buf, err := ioutil.ReadFile(filepath.Join(dir, ".dockerignore"))
+	require.NoError(t, err)
+	assert.Contains(t, string(buf), "# BEGIN update-gitignore: macOS repo=github/gitignore path=Global/macOS.gitignore sha=135767fc075ec33f7f9966fb28968113e32b697e\n# General\n**/.DS_Store\n")
+
+	// rerunning leaves the converted blocks alone
+	s = newState(nil, "valid", "-C", dir, "update", "-target", "dockerignore")
+	require.NoError(t, s.ParseArguments())
+
+	cmd, err = s.Command()
+	require.NoError(t, err)
+	assert.Equal(t, ExitStatus(0), cmd.Run())
+	require.NoError(t, s.Logger().ShutdownLoggers())
+	assert.Contains(t, s.Stderr.(*bytes.Buffer).String(), ".dockerignore is up to date")
+	assert.NotContains(t, s.Stderr.(*bytes.Buffer).String(), "merging")
+	assert.NotContains(t, s.Stderr.(*bytes.Buffer).String(), "differ")
+}
+
+func TestUpdateCommand_ConversionManifest(t *testing.T) {
+	t.Parallel()
+
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	writeFile(t, filepath.Join(dir, ".gitignore.toml"), "templates = [\"Go\"]\nlocal = [\"/report.txt\", \"*.tmp\"]\n")
+
+	s := newState(nil, "valid", "-C", dir, "update", "-target", "dockerignore")
+	require.NoError(t, s.ParseArguments())
+
+	cmd, err := s.Command()
+	require.NoError(t, err)
+	assert.Equal(t, ExitStatus(0), cmd.Run())
+	require.NoError(t, s.Logger().ShutdownLoggers())
+
+	buf, err := ioutil.ReadFile(filepath.Join(dir, ".dockerignore"))
+	require.NoError(t, err)
+	assert.Contains(t, string(buf), "# BEGIN update-gitignore: Go repo=github/gitignore path=Go.gitignore sha=f2dd9554a12fd7acdc62e60e8eccae086f718be2\n")
+	assert.Contains(t, string(buf), "**/*.exe\n")
+	assert.Contains(t, string(buf), "# BEGIN update-gitignore: local\nreport.txt\n**/*.tmp\n# END update-gitignore: local\n")
+
+	// the gitignore file itself isn't written
+	_, err = os.Stat(filepath.Join(dir, ".gitignore"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestConversionWarnings(t *testing.T) {
+	t.Parallel()
+
+	_, notes := Conversions["dockerignore"].Convert(ParseGitignoreString("/bin\nlogs/\n*.tmp\n"))
+	assert.Equal(t, []string{
+		`.dockerignore: Go: 2 rules differ, e.g. "logs/" (line 2): rules without a slash only match at the root of the build context; prefixed with **/`,
+		`.dockerignore: Go: 1 rule differs, e.g. "logs/" (line 2): a trailing / is ignored, so the rule also matches files`,
+	}, conversionWarnings(".dockerignore", "Go", notes))
+	assert.Empty(t, conversionWarnings(".dockerignore", "Go", nil))
+}
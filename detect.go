@@ -0,0 +1,209 @@
+package state
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// maxEvidence is the number of matching paths printed for each detected template in text output.
+const maxEvidence = 3
+
+type (
+	detectCommand State
+
+	// DetectRule suggests a template when any of its markers is found in the working tree. Markers are globs
+	// matched against the base name of each path; markers ending in a slash only match directories and the others
+	// only match files.
+	DetectRule struct {
+		Template string
+		Markers  []string
+	}
+
+	// Detection is a template suggested by the working tree and the paths that suggested it.
+	Detection struct {
+		Template string   `json:"template"`
+		Evidence []string `json:"evidence"`
+	}
+)
+
+// DefaultDetectRules maps common marker files to the names of templates in github/gitignore.
+var DefaultDetectRules = []DetectRule{
+	{"Go", []string{"go.mod", "*.go"}},
+	{"Node", []string{"package.json"}},
+	{"Rust", []string{"Cargo.toml"}},
+	{"Python", []string{"pyproject.toml", "setup.py", "requirements.txt", "Pipfile", "*.py"}},
+	{"Ruby", []string{"Gemfile", "*.gemspec"}},
+	{"Java", []string{"*.java"}},
+	{"Maven", []string{"pom.xml"}},
+	{"Gradle", []string{"build.gradle", "build.gradle.kts", "gradlew"}},
+	{"Scala", []string{"build.sbt"}},
+	{"Kotlin", []string{"*.kt"}},
+	{"Android", []string{"AndroidManifest.xml"}},
+	{"Composer", []string{"composer.json"}},
+	{"Dart", []string{"pubspec.yaml"}},
+	{"Elixir", []string{"mix.exs"}},
+	{"Haskell", []string{"*.cabal", "stack.yaml"}},
+	{"Swift", []string{"Package.swift"}},
+	{"Xcode", []string{"*.xcodeproj/", "*.xcworkspace/"}},
+	{"C", []string{"*.c"}},
+	{"C++", []string{"*.cpp", "*.cc", "*.hpp"}},
+	{"CMake", []string{"CMakeLists.txt"}},
+	{"R", []string{"*.Rproj", "*.R"}},
+	{"Terraform", []string{"*.tf"}},
+	{"VisualStudio", []string{"*.sln", "*.csproj", "*.vbproj", "*.fsproj"}},
+	{"VisualStudioCode", []string{".vscode/"}},
+	{"JetBrains", []string{".idea/", "*.iml"}},
+	{"macOS", []string{".DS_Store"}},
+}
+
+// DependencyDirs are the names of directories holding dependencies or build output, which are never searched for
+// markers since their files belong to other projects.
+var DependencyDirs = []string{
+	"node_modules", "bower_components", "vendor", ".venv", "venv", "__pycache__", ".tox", "target", ".gradle",
+	".terraform", "dist", "build",
+}
+
+func (c *detectCommand) GetName() string { return "detect" }
+
+func (c *detectCommand) Flags(fs *flag.FlagSet) {
+	fs.BoolVar(&c.names, "names", false, "only print template names, e.g. to pass to update")
+}
+
+// Run prints the templates suggested by the working tree. Returns 0 if any were found, 1 if none were and 2 on
+// error.
+func (c *detectCommand) Run() ExitStatus {
+	s := (*State)(c)
+	logger := s.Logger()
+
+	detections, err := s.Detect(DefaultDetectRules)
+	if err != nil {
+		logger.Error(err.Error())
+		return 2
+	}
+
+	switch {
+	case s.format == "json":
+		if detections == nil {
+			detections = []Detection{}
+		}
+		enc := json.NewEncoder(s.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(detections)
+	case s.names:
+		for _, d := range detections {
+			if _, err = fmt.Fprintln(s.Stdout, d.Template); err != nil {
+				break
+			}
+		}
+	default:
+		for _, d := range detections {
+			if _, err = fmt.Fprintf(s.Stdout, "%s\t%s\n", d.Template, d.summary()); err != nil {
+				break
+			}
+		}
+	}
+
+	if err != nil {
+		logger.Error(err.Error())
+		return 2
+	}
+
+	if len(detections) == 0 {
+		return 1
+	}
+
+	return 0
+}
+
+func (d Detection) summary() string {
+	if len(d.Evidence) <= maxEvidence {
+		return strings.Join(d.Evidence, ", ")
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(d.Evidence[:maxEvidence], ", "), len(d.Evidence)-maxEvidence)
+}
+
+// Detect walks the working tree and returns the templates suggested by the rules, in the order of the rules.
+// Directories ignored by the local gitignore file and DependencyDirs are not searched, but still count as evidence
+// themselves. Unreadable paths are skipped with a warning.
+func (s *State) Detect(rules []DetectRule) ([]Detection, error) {
+	g, err := s.ReadGitignore(GitignoreFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		ignore   = NewMatcher(g, GitignoreFile)
+		root     = s.Path()
+		evidence = make([][]string, len(rules))
+	)
+
+	err = filepath.Walk(root, func(name string, info os.FileInfo, walkErr error) error {
+		if walkErr != nil && (name == root || info == nil) {
+			if name == root {
+				return walkErr
+			}
+			s.Logger().Warnf("skipping %s: %v", name, walkErr)
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, name)
+		if err != nil || rel == "." {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		for idx, rule := range rules {
+			if rule.matches(path.Base(rel), info.IsDir()) {
+				evidence[idx] = append(evidence[idx], rel)
+			}
+		}
+
+		if walkErr != nil {
+			// a directory that can't be read
+			s.Logger().Warnf("skipping %s: %v", name, walkErr)
+			return filepath.SkipDir
+		}
+
+		if info.IsDir() && skipDir(info.Name(), rel, ignore) {
+			return filepath.SkipDir
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var rv []Detection
+	for idx, rule := range rules {
+		if len(evidence[idx]) > 0 {
+			rv = append(rv, Detection{Template: rule.Template, Evidence: evidence[idx]})
+		}
+	}
+
+	return rv, nil
+}
+
+// skipDir reports whether the directory shouldn't be searched: it is the git directory, a dependency directory or
+// ignored by the matcher.
+func skipDir(base, rel string, ignore *Matcher) bool {
+	return base == ".git" || contains(DependencyDirs, base) || ignore.Ignored(rel, true)
+}
+
+func (r DetectRule) matches(base string, isDir bool) bool {
+	for _, marker := range r.Markers {
+		glob := strings.TrimSuffix(marker, "/")
+		if (glob != marker) != isDir {
+			continue
+		}
+		if ok, _ := path.Match(glob, base); ok {
+			return true
+		}
+	}
+	return false
+}
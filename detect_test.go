@@ -0,0 +1,155 @@
+package state
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectCommand_Run(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	for _, name := range []string{"go.mod", "main.go", "cmd/app/main.go", "cmd/tool/main.go", "web/package.json", "node_modules/dep/package.json", "bin/setup.py"} {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755))
+		writeFile(t, filepath.Join(dir, name), "")
+	}
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, ".idea"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, ".git", "hooks"), 0755))
+	writeFile(t, filepath.Join(dir, ".git", "hooks", "pre-commit.py"), "")
+	writeFile(t, filepath.Join(dir, ".gitignore"), "node_modules/\n/bin/\n")
+
+	cases := []struct {
+		name     string
+		args     []string
+		stdout   string
+		exitcode ExitStatus
+	}{
+		{
+			"text",
+			[]string{"detect"},
+			chain(
+				"Go\tcmd/app/main.go, cmd/tool/main.go, go.mod and 1 more\n",
+				"Node\tweb/package.json\n",
+				"JetBrains\t.idea\n",
+			),
+			0,
+		},
+		{
+			"names",
+			[]string{"detect", "-names"},
+			"Go\nNode\nJetBrains\n",
+			0,
+		},
+		{
+			"json",
+			[]string{"-format", "json", "detect"},
+			chain(
+				"[\n",
+				"  {\n",
+				"    \"template\": \"Go\",\n",
+				"    \"evidence\": [\n",
+				"      \"cmd/app/main.go\",\n",
+				"      \"cmd/tool/main.go\",\n",
+				"      \"go.mod\",\n",
+				"      \"main.go\"\n",
+				"    ]\n",
+				"  },\n",
+				"  {\n",
+				"    \"template\": \"Node\",\n",
+				"    \"evidence\": [\n",
+				"      \"web/package.json\"\n",
+				"    ]\n",
+				"  },\n",
+				"  {\n",
+				"    \"template\": \"JetBrains\",\n",
+				"    \"evidence\": [\n",
+				"      \".idea\"\n",
+				"    ]\n",
+				"  }\n",
+				"]\n",
+			),
+			0,
+		},
+	}
+
+	t.Parallel()
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			s := newState(nil, "valid", append([]string{"-C", dir}, tt.args...)...)
+			defer s.Logger().ShutdownLoggers()
+			require.NoError(t, s.ParseArguments())
+
+			cmd, err := s.Command()
+			require.NoError(t, err)
+			assert.Equal(t, "detect", cmd.GetName())
+			assert.Equal(t, tt.exitcode, cmd.Run())
+			if tt.stdout != "" {
+				assert.Equal(t, tt.stdout, s.Stdout.(*bytes.Buffer).String())
+			}
+		})
+	}
+}
+
+func TestDetectCommand_Empty(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	s := newState(nil, "valid", "-C", dir, "detect")
+	defer s.Logger().ShutdownLoggers()
+	require.NoError(t, s.ParseArguments())
+
+	cmd, err := s.Command()
+	require.NoError(t, err)
+	assert.Equal(t, ExitStatus(1), cmd.Run())
+	assert.Empty(t, s.Stdout.(*bytes.Buffer).String())
+}
+
+func TestState_Detect_DependencyDirs(t *testing.T) {
+	t.Parallel()
+
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	// no .gitignore lists the dependency directories
+	for _, name := range []string{"package.json", "node_modules/gyp/setup.py", "vendor/x/Cargo.toml", ".venv/lib/site.py"} {
+		writeFile(t, filepath.Join(dir, filepath.FromSlash(name)), "")
+	}
+
+	s := &State{App: newApp(nil, "-C", dir, "detect")}
+	defer s.Logger().ShutdownLoggers()
+	require.NoError(t, s.ParseArguments())
+
+	detections, err := s.Detect(DefaultDetectRules)
+	require.NoError(t, err)
+	assert.Equal(t, []Detection{{Template: "Node", Evidence: []string{"package.json"}}}, detections)
+}
+
+func TestState_Detect_Unreadable(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("permissions aren't enforced for root")
+	}
+	t.Parallel()
+
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	writeFile(t, filepath.Join(dir, "go.mod"), "")
+	writeFile(t, filepath.Join(dir, "locked", "setup.py"), "")
+	require.NoError(t, os.Chmod(filepath.Join(dir, "locked"), 0))
+	defer os.Chmod(filepath.Join(dir, "locked"), 0755)
+
+	s := &State{App: newApp(nil, "-C", dir, "detect")}
+	require.NoError(t, s.ParseArguments())
+
+	detections, err := s.Detect(DefaultDetectRules)
+	require.NoError(t, err)
+	require.NoError(t, s.Logger().ShutdownLoggers())
+	assert.Equal(t, []Detection{{Template: "Go", Evidence: []string{"go.mod"}}}, detections)
+	assert.Contains(t, s.Stderr.(*bytes.Buffer).String(), "skipping "+filepath.Join(dir, "locked"))
+}
@@ -0,0 +1,159 @@
+package state
+
+import (
+	"fmt"
+	"strings"
+)
+
+type (
+	// DiffOp is a single line of a line-based diff.
+	DiffOp struct {
+		// Kind is ' ' for lines in both inputs, '-' for lines only in the first and '+' for lines only in the second.
+		Kind byte
+		Text string
+	}
+)
+
+// DiffLines computes a minimal line diff transforming a into b using the longest common subsequence.
+func DiffLines(a, b []string) []DiffOp {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var (
+		rv   []DiffOp
+		i, j int
+	)
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			rv = append(rv, DiffOp{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			rv = append(rv, DiffOp{'-', a[i]})
+			i++
+		default:
+			rv = append(rv, DiffOp{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		rv = append(rv, DiffOp{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		rv = append(rv, DiffOp{'+', b[j]})
+	}
+
+	return rv
+}
+
+// UnifiedDiff renders the differences between the two texts in unified diff format with the given number of
+// context lines. Returns the empty string if the texts are equal.
+func UnifiedDiff(fromName, toName, a, b string, context int) string {
+	if a == b {
+		return ""
+	}
+
+	ops := DiffLines(splitLines(a), splitLines(b))
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromName, toName)
+
+	for start := 0; start < len(ops); {
+		// find the next change
+		for start < len(ops) && ops[start].Kind == ' ' {
+			start++
+		}
+		if start == len(ops) {
+			break
+		}
+
+		// extend the hunk until there are more than 2*context unchanged lines in a row
+		end := start
+		for end < len(ops) {
+			if ops[end].Kind != ' ' {
+				end++
+				continue
+			}
+			run := end
+			for run < len(ops) && ops[run].Kind == ' ' {
+				run++
+			}
+			if run == len(ops) || run-end > 2*context {
+				break
+			}
+			end = run
+		}
+
+		first := start - context
+		if first < 0 {
+			first = 0
+		}
+		last := end + context
+		if last > len(ops) {
+			last = len(ops)
+		}
+
+		// line numbers of the first line of the hunk in each input
+		aLine, bLine := 1, 1
+		for _, op := range ops[:first] {
+			if op.Kind != '+' {
+				aLine++
+			}
+			if op.Kind != '-' {
+				bLine++
+			}
+		}
+
+		var aCount, bCount int
+		for _, op := range ops[first:last] {
+			if op.Kind != '+' {
+				aCount++
+			}
+			if op.Kind != '-' {
+				bCount++
+			}
+		}
+
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(aLine, aCount), hunkRange(bLine, bCount))
+		for _, op := range ops[first:last] {
+			fmt.Fprintf(&out, "%c%s\n", op.Kind, op.Text)
+		}
+
+		start = last
+	}
+
+	return out.String()
+}
+
+func hunkRange(line, count int) string {
+	if count == 0 {
+		line--
+	}
+	if count == 1 {
+		return fmt.Sprint(line)
+	}
+	return fmt.Sprintf("%d,%d", line, count)
+}
+
+// splitLines splits text into lines without their newlines.
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
@@ -0,0 +1,68 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	cases := []struct {
+		name   string
+		a, b   string
+		output string
+	}{
+		{"equal", "a\nb\n", "a\nb\n", ""},
+		{
+			"append",
+			"a\nb\n",
+			"a\nb\nc\n",
+			chain(
+				"--- a\n",
+				"+++ b\n",
+				"@@ -1,2 +1,3 @@\n",
+				" a\n",
+				" b\n",
+				"+c\n",
+			),
+		},
+		{
+			"from empty",
+			"",
+			"a\n",
+			chain(
+				"--- a\n",
+				"+++ b\n",
+				"@@ -0,0 +1 @@\n",
+				"+a\n",
+			),
+		},
+		{
+			"two hunks",
+			"1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+			"0\n1\n2\n3\n4\n5\n6\n7\n8\nnine\n10\n",
+			chain(
+				"--- a\n",
+				"+++ b\n",
+				"@@ -1,2 +1,3 @@\n",
+				"+0\n",
+				" 1\n",
+				" 2\n",
+				"@@ -7,4 +8,4 @@\n",
+				" 7\n",
+				" 8\n",
+				"-9\n",
+				"+nine\n",
+				" 10\n",
+			),
+		},
+	}
+
+	t.Parallel()
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.output, UnifiedDiff("a", "b", tt.a, tt.b, 2))
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package state
+
+import (
+	"flag"
+	"sort"
+)
+
+type dumpCommand State
+
+func (c *dumpCommand) GetName() string { return "dump" }
+
+func (c *dumpCommand) Flags(fs *flag.FlagSet) {
+	fs.BoolVar(&c.dedupe, "dedupe", false, "drop patterns duplicated by an earlier template")
+}
+
+// Run writes the selected templates to stdout as managed blocks.
+func (c *dumpCommand) Run() ExitStatus {
+	s := (*State)(c)
+	logger := s.Logger()
+
+	if len(s.templates) == 0 {
+		logger.Error("dump requires at least one template")
+		return 2
+	}
+
+	sections, err := s.fetchSections(s.templates)
+	if err != nil {
+		logger.Error(err.Error())
+		return 1
+	}
+
+	if s.dedupe {
+		dropped := Dedupe(sections)
+		names := make([]string, 0, len(dropped))
+		for name := range dropped {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			logger.Infof("dedupe: dropped %d duplicate lines from %s", dropped[name], name)
+		}
+	}
+
+	for _, d := range AnalyzeConflicts(NewSectionMatcher(sections).Rules()) {
+		logger.Warn(d.String())
+	}
+
+	if _, err := Compose(sections).WriteTo(s.Stdout); err != nil {
+		logger.Error(err.Error())
+		return 1
+	}
+
+	return 0
+}
+
+// fetchSections resolves the named templates in the catalog and downloads them.
+func (s *State) fetchSections(names []string) ([]*Section, error) {
+	cat, err := s.Catalog()
+	if err != nil {
+		return nil, err
+	}
+
+	templates, err := cat.Resolve(names...)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Sections(cat, templates)
+}
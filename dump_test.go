@@ -0,0 +1,97 @@
+package state
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDumpCommand_Run(t *testing.T) {
+	cases := []struct {
+		name     string
+		args     []string
+		stdout   string
+		stderr   string
+		blocks   int
+		exitcode ExitStatus
+	}{
+		{
+			"two templates",
+			[]string{"dump", "Linux", "VisualStudioCode"},
+			chain(
+				"# BEGIN update-gitignore: Linux repo=github/gitignore path=Global/Linux.gitignore sha=b56bf65d85583b03eeccfaa2a927084583a33e91\n",
+				"*~\n",
+				"\n",
+				"# temporary files which can be created if a process still has a handle open of a deleted file\n",
+				".fuse_hidden*\n",
+				"\n",
+				"# KDE directory preferences\n",
+				".directory\n",
+				"\n",
+				"# Linux trash folder which might appear on any partition or disk\n",
+				".Trash-*\n",
+				"\n",
+				"# .nfs files are created when an open file is removed but is still being accessed\n",
+				".nfs*\n",
+				"# END update-gitignore: Linux\n",
+				"\n",
+				"# BEGIN update-gitignore: VisualStudioCode repo=github/gitignore path=Global/VisualStudioCode.gitignore sha=0511e2b51f0d42d1dff69f4ed5df03c6649ca356\n",
+				".vscode/*\n",
+				"!.vscode/settings.json\n",
+				"!.vscode/tasks.json\n",
+				"!.vscode/launch.json\n",
+				"!.vscode/extensions.json\n",
+				"# END update-gitignore: VisualStudioCode\n",
+			),
+			"",
+			2,
+			0,
+		},
+		{
+			"dedupe",
+			[]string{"dump", "-dedupe", "VisualStudioCode", "Go", "go"},
+			"",
+			"",
+			2,
+			0,
+		},
+		{
+			"missing",
+			[]string{"dump", "Go", "Missing"},
+			"",
+			"template Missing not found in github/gitignore",
+			0,
+			1,
+		},
+	}
+
+	t.Parallel()
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			s := newState(nil, "valid", append([]string{"-timeout=0"}, tt.args...)...)
+			require.NoError(t, s.ParseArguments())
+
+			cmd, err := s.Command()
+			require.NoError(t, err)
+			assert.Equal(t, "dump", cmd.GetName())
+			assert.Equal(t, tt.exitcode, cmd.Run())
+			require.NoError(t, s.Logger().ShutdownLoggers())
+
+			stdout := s.Stdout.(*bytes.Buffer).String()
+			if tt.stdout != "" {
+				assert.Equal(t, tt.stdout, stdout)
+			}
+			assert.Contains(t, s.Stderr.(*bytes.Buffer).String(), tt.stderr)
+
+			if tt.exitcode == 0 {
+				g := ParseGitignoreString(stdout)
+				blocks, err := g.Blocks()
+				require.NoError(t, err)
+				assert.Len(t, blocks, tt.blocks)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package state
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+)
+
+// TrackedFiles lists the files tracked by git in the working directory, relative to it. Returns nil without error
+// if the working directory is not inside a git repository.
+func (s *State) TrackedFiles() ([]string, error) {
+	out, err := s.git("ls-files", "-z")
+	if err != nil {
+		if _, ok := err.(*exec.ExitError); ok {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var rv []string
+	for _, name := range strings.Split(string(out), "\x00") {
+		if name != "" {
+			rv = append(rv, name)
+		}
+	}
+
+	return rv, nil
+}
+
+// git runs a git subcommand in the working directory and returns its stdout.
+func (s *State) git(args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(s.Context, "git", append([]string{"-C", s.dir}, args...)...)
+	cmd.Env = append(append([]string{}, s.Environment...), "GIT_TERMINAL_PROMPT=0")
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		s.Logger().Debugf("git %s: %s", strings.Join(args, " "), strings.TrimSpace(stderr.String()))
+	}
+
+	return out, err
+}
@@ -2,7 +2,10 @@ package state
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
+	"strings"
 	"sync"
 
 	"github.com/google/go-github/v24/github"
@@ -10,7 +13,7 @@ import (
 )
 
 var (
-	// ErrTokenNotFound is returned if the client was unable to locate a GITHUB_TOKEN environment variable
+	// ErrTokenNotFound is returned if none of the token providers were able to supply a token
 	ErrTokenNotFound = errors.New("token not found")
 )
 
@@ -18,27 +21,62 @@ type Client struct {
 	state      *State
 	owner      string
 	repo       string
+	host       string
 	httpClient *http.Client
 
+	providers  []TokenProvider
+	authMu     sync.Mutex
+	authDone   bool
+	authToken  *oauth2.Token
+	authSource string
+	authErr    error
+
 	clientMu sync.Mutex
 	client   *github.Client
 }
 
+// Token consults the client token providers in order and returns the first token found. The providers are only
+// consulted the first time; later calls return the same result.
 func (c *Client) Token() (*oauth2.Token, error) {
-	value, _ := c.state.LookupEnv("GITHUB_TOKEN")
-	if value == "" {
-		return nil, ErrTokenNotFound
+	c.authMu.Lock()
+	defer c.authMu.Unlock()
+
+	if !c.authDone {
+		c.authDone = true
+		value, source, err := LookupToken(c.state, c.host, c.providers)
+		if err != nil {
+			c.authErr = err
+		} else {
+			c.state.Logger().Debugf("using token from %s for %s", source, c.host)
+			c.authToken = &oauth2.Token{AccessToken: value}
+			c.authSource = source
+		}
 	}
 
-	return &oauth2.Token{AccessToken: value}, nil
+	return c.authToken, c.authErr
+}
+
+// AuthSource returns the name of the provider that supplied the last token, or the empty string if the client is
+// anonymous.
+func (c *Client) AuthSource() string {
+	c.authMu.Lock()
+	defer c.authMu.Unlock()
+	return c.authSource
 }
 
+// SetTokenProviders replaces the chain of providers consulted by Token.
+func (c *Client) SetTokenProviders(providers []TokenProvider) {
+	c.authMu.Lock()
+	defer c.authMu.Unlock()
+	c.providers = providers
+	c.authDone, c.authToken, c.authSource, c.authErr = false, nil, "", nil
+}
+
+// SetHTTPClient sets the client used for requests. With nil, requests are authenticated with the token found by
+// Token when the first request is made, or are anonymous if there is none.
 func (c *Client) SetHTTPClient(httpClient *http.Client) {
 	if httpClient == nil {
-		_, err := c.Token()
-		if err == nil {
-			httpClient = oauth2.NewClient(c.state.Context, c)
-		}
+		httpClient = &http.Client{Transport: &tokenTransport{client: c, base: http.DefaultTransport}}
 	}
 
 	c.clientMu.Lock()
@@ -47,6 +85,29 @@ func (c *Client) SetHTTPClient(httpClient *http.Client) {
 	c.clientMu.Unlock()
 }
 
+// tokenTransport authenticates requests with the token of the client, if there is one.
+type tokenTransport struct {
+	client *Client
+	base   http.RoundTripper
+}
+
+func (t *tokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	tok, err := t.client.Token()
+	if err != nil {
+		return t.base.RoundTrip(req)
+	}
+
+	// a RoundTripper must not modify the request
+	authed := new(http.Request)
+	*authed = *req
+	authed.Header = make(http.Header, len(req.Header)+1)
+	for k, v := range req.Header {
+		authed.Header[k] = v
+	}
+	tok.SetAuthHeader(authed)
+	return t.base.RoundTrip(authed)
+}
+
 func (c *Client) HTTPClient() *http.Client {
 	return c.httpClient
 }
@@ -68,6 +129,26 @@ func (c *Client) GetUser() (*github.User, error) {
 	return user, err
 }
 
+// GetUserScopes returns the authenticated user along with the OAuth scopes granted to the token.
+func (c *Client) GetUserScopes() (*github.User, []string, error) {
+	cl := c.GitHubClient()
+	ctx, cancel := c.state.deadline()
+	defer cancel()
+	user, resp, err := cl.Users.Get(ctx, "")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var scopes []string
+	for _, scope := range strings.Split(resp.Header.Get("X-OAuth-Scopes"), ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	return user, scopes, nil
+}
+
 func (c *Client) GetRateLimits() (*github.RateLimits, error) {
 	cl := c.GitHubClient()
 	ctx, cancel := c.state.deadline()
@@ -107,3 +188,66 @@ func (c *Client) GetBlob(sha string) (*github.Blob, error) {
 	blob, _, err := cl.Git.GetBlob(ctx, c.owner, c.repo, sha)
 	return blob, err
 }
+
+// GetLastCommit returns the most recent commit reachable from ref that touched the path, or nil if there is none.
+func (c *Client) GetLastCommit(ref, path string) (*github.RepositoryCommit, error) {
+	cl := c.GitHubClient()
+	ctx, cancel := c.state.deadline()
+	defer cancel()
+	opts := &github.CommitsListOptions{SHA: ref, Path: path, ListOptions: github.ListOptions{PerPage: 1}}
+	commits, _, err := cl.Repositories.ListCommits(ctx, c.owner, c.repo, opts)
+	if err != nil || len(commits) == 0 {
+		return nil, err
+	}
+	return commits[0], nil
+}
+
+// BlobURL returns the address of the file at ref on the web.
+func (c *Client) BlobURL(ref, path string) string {
+	return "https://" + c.host + "/" + c.owner + "/" + c.repo + "/blob/" + escapeSegments(ref) + "/" + escapeSegments(path)
+}
+
+// escapeSegments escapes each slash separated segment of a ref or path for use in a URL.
+func escapeSegments(name string) string {
+	segments := strings.Split(name, "/")
+	for idx, seg := range segments {
+		segments[idx] = url.PathEscape(seg)
+	}
+	return strings.Join(segments, "/")
+}
+
+// ListCommits returns a page of the commits reachable from ref that touched the path, newest first, and the number
+// of the next page, or 0 if it is the last.
+func (c *Client) ListCommits(ref, path string, page int) ([]*github.RepositoryCommit, int, error) {
+	cl := c.GitHubClient()
+	ctx, cancel := c.state.deadline()
+	defer cancel()
+	opts := &github.CommitsListOptions{SHA: ref, Path: path, ListOptions: github.ListOptions{Page: page, PerPage: 100}}
+	commits, resp, err := cl.Repositories.ListCommits(ctx, c.owner, c.repo, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	return commits, resp.NextPage, nil
+}
+
+// CompareCommits returns a page of the commits reachable from head but not from base, oldest first, and the number
+// of the next page, or 0 if it is the last.
+func (c *Client) CompareCommits(base, head string, page int) (*github.CommitsComparison, int, error) {
+	cl := c.GitHubClient()
+	ctx, cancel := c.state.deadline()
+	defer cancel()
+
+	// go-github doesn't page comparisons
+	u := fmt.Sprintf("repos/%s/%s/compare/%s...%s?per_page=100&page=%d", c.owner, c.repo, base, head, page)
+	req, err := cl.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	cmp := new(github.CommitsComparison)
+	resp, err := cl.Do(ctx, req, cmp)
+	if err != nil {
+		return nil, 0, err
+	}
+	return cmp, resp.NextPage, nil
+}
@@ -11,6 +11,7 @@ import (
 	"path"
 	"path/filepath"
 	"reflect"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -22,10 +23,35 @@ import (
 	"github.com/demosdemon/golang-app-framework/app"
 )
 
+// pinnedEnvironment keeps tests from reading the host's git and update-gitignore configuration and credentials
+// unless a test sets the variables itself.
+var pinnedEnvironment = []string{
+	"HOME=",
+	"GIT_CONFIG_NOSYSTEM=1",
+	"GIT_CONFIG_GLOBAL=" + os.DevNull,
+}
+
+func init() {
+	// keep the host's system configuration out of the tests
+	SystemConfigFile = os.DevNull
+}
+
 func newApp(environ []string, args ...string) *app.App {
+	env := append([]string{}, environ...)
+	for _, pinned := range pinnedEnvironment {
+		key := pinned[:strings.Index(pinned, "=")+1]
+		found := false
+		for _, kv := range environ {
+			found = found || strings.HasPrefix(kv, key)
+		}
+		if !found {
+			env = append(env, pinned)
+		}
+	}
+
 	return &app.App{
 		Arguments:   args,
-		Environment: environ,
+		Environment: env,
 		Context:     context.Background(),
 		Stdin:       new(bytes.Buffer),
 		Stdout:      new(bytes.Buffer),
@@ -111,6 +137,13 @@ func newClient(env []string, key string) (*app.App, *Client) {
 	return a, c
 }
 
+func newState(env []string, key string, args ...string) *State {
+	return &State{
+		App:        newApp(env, args...),
+		httpClient: &http.Client{Transport: newReplay(key)},
+	}
+}
+
 func strptr(s string) *string {
 	return &s
 }
@@ -489,3 +522,78 @@ func TestClient_GetTree(t *testing.T) {
 		})
 	}
 }
+
+type countingProvider struct {
+	calls int
+}
+
+func (p *countingProvider) Name() string { return "counting" }
+
+func (p *countingProvider) Token(s *State, host string) (string, error) {
+	p.calls++
+	return "secret", nil
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestClient_BlobURL(t *testing.T) {
+	cases := []struct {
+		name     string
+		ref      string
+		path     string
+		expected string
+	}{
+		{"plain", "master", "Global/macOS.gitignore", "https://github.com/github/gitignore/blob/master/Global/macOS.gitignore"},
+		{"ref with a slash", "release/v1", "Go.gitignore", "https://github.com/github/gitignore/blob/release/v1/Go.gitignore"},
+		{"special characters", "master", "community/C#/Visual Studio?.gitignore", "https://github.com/github/gitignore/blob/master/community/C%23/Visual%20Studio%3F.gitignore"},
+	}
+
+	a := newApp(nil, "test")
+	defer a.Logger().ShutdownLoggers()
+	s := State{App: a}
+	require.NoError(t, s.ParseArguments())
+	c, err := s.Client()
+	require.NoError(t, err)
+
+	t.Parallel()
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, c.BlobURL(tt.ref, tt.path))
+		})
+	}
+}
+
+func TestClient_Token_Lazy(t *testing.T) {
+	s := State{App: newApp(nil, "test")}
+	defer s.Logger().ShutdownLoggers()
+	require.NoError(t, s.ParseArguments())
+
+	c, err := s.Client()
+	require.NoError(t, err)
+
+	p := new(countingProvider)
+	c.SetTokenProviders([]TokenProvider{p})
+	c.SetHTTPClient(nil)
+	assert.Equal(t, 0, p.calls)
+
+	var headers []string
+	tr := &tokenTransport{client: c, base: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		headers = append(headers, req.Header.Get("Authorization"))
+		return &http.Response{StatusCode: http.StatusNoContent, Body: http.NoBody, Request: req}, nil
+	})}
+
+	for i := 0; i < 2; i++ {
+		req, err := http.NewRequest("GET", "https://api.github.com/user", nil)
+		require.NoError(t, err)
+		_, err = tr.RoundTrip(req)
+		require.NoError(t, err)
+		assert.Empty(t, req.Header.Get("Authorization"))
+	}
+
+	assert.Equal(t, []string{"Bearer secret", "Bearer secret"}, headers)
+	assert.Equal(t, 1, p.calls)
+	assert.Equal(t, "counting", c.AuthSource())
+}
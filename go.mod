@@ -3,6 +3,7 @@ module github.com/demosdemon/update-gitignore
 go 1.12
 
 require (
+	github.com/BurntSushi/toml v0.3.1
 	github.com/aphistic/gomol v0.0.0-20190314031446-1546845ba714
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/demosdemon/golang-app-framework v1.0.1
@@ -14,5 +15,6 @@ require (
 	github.com/stretchr/testify v1.3.0
 	golang.org/x/net v0.0.0-20190320064053-1272bf9dcd53 // indirect
 	golang.org/x/oauth2 v0.0.0-20190319182350-c85d3e98c914
-	golang.org/x/sys v0.0.0-20190318195719-6c81ef8f67ca // indirect
+	golang.org/x/sys v0.0.0-20190318195719-6c81ef8f67ca
+	gopkg.in/yaml.v2 v2.2.2
 )
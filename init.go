@@ -0,0 +1,157 @@
+package state
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// localRules heads the section of a new gitignore file for rules that aren't managed by update-gitignore.
+const localRules = "# Local rules: project specific patterns below are not changed by update-gitignore"
+
+type initCommand State
+
+func (c *initCommand) GetName() string { return "init" }
+
+func (c *initCommand) Flags(fs *flag.FlagSet) {
+	(*State)(c).writeFlags(fs)
+	fs.BoolVar(&c.force, "force", false, "overwrite an existing .gitignore")
+	fs.BoolVar(&c.yes, "yes", false, "accept detected templates without prompting")
+}
+
+// Run writes a new gitignore file and lockfile from the detected and named templates. Detected templates are
+// confirmed interactively unless -yes is set.
+func (c *initCommand) Run() ExitStatus {
+	s := (*State)(c)
+	logger := s.Logger()
+
+	if err := s.Init(); err != nil {
+		logger.Error(err.Error())
+		return 1
+	}
+
+	return 0
+}
+
+// Init bootstraps the gitignore file and lockfile in the working directory.
+func (s *State) Init() error {
+	logger := s.Logger()
+
+	if _, err := os.Stat(s.Path(GitignoreFile)); err == nil && !s.force {
+		return fmt.Errorf("%s already exists; use -force to overwrite it", GitignoreFile)
+	}
+
+	detections, err := s.Detect(DefaultDetectRules)
+	if err != nil {
+		return err
+	}
+
+	cat, err := s.Catalog()
+	if err != nil {
+		return err
+	}
+
+	var available []Detection
+	for _, d := range detections {
+		if cat.Find(d.Template) == nil {
+			logger.Warnf("detected %s but there is no such template in %s", d.Template, cat.Repo)
+			continue
+		}
+		available = append(available, d)
+	}
+
+	names, err := s.selectTemplates(available)
+	if err != nil {
+		return err
+	}
+
+	templates, err := cat.Resolve(names...)
+	if err != nil {
+		return err
+	}
+
+	sections, err := s.Sections(cat, templates)
+	if err != nil {
+		return err
+	}
+
+	for _, d := range AnalyzeConflicts(NewSectionMatcher(sections).Rules()) {
+		logger.Warn(d.String())
+	}
+
+	g := Compose(sections)
+	var text strings.Builder
+	text.WriteString(g.String())
+	if len(sections) > 0 {
+		text.WriteString("\n")
+	}
+	text.WriteString(localRules + "\n")
+	g = ParseGitignoreString(text.String())
+
+	lock, err := NewLock(cat, g)
+	if err != nil {
+		return err
+	}
+
+	if err := s.WriteGitignore(GitignoreFile, g); err != nil {
+		return err
+	}
+
+	if err := s.WriteLock(lock); err != nil {
+		return err
+	}
+
+	logger.Infof("wrote %s with %d templates", GitignoreFile, len(sections))
+	return nil
+}
+
+// selectTemplates returns the names of the templates to include: the detected templates the user accepts followed
+// by the templates named on the command line and any named at the prompt.
+func (s *State) selectTemplates(detections []Detection) ([]string, error) {
+	var names []string
+
+	if s.yes {
+		for _, d := range detections {
+			names = append(names, d.Template)
+		}
+		return append(names, s.templates...), nil
+	}
+
+	in := bufio.NewReader(s.Stdin)
+	for _, d := range detections {
+		answer, err := s.prompt(in, "Include %s (%s)? [Y/n] ", d.Template, d.summary())
+		if err != nil {
+			return nil, err
+		}
+		switch strings.ToLower(answer) {
+		case "n", "no":
+		default:
+			names = append(names, d.Template)
+		}
+	}
+
+	names = append(names, s.templates...)
+
+	answer, err := s.prompt(in, "Other templates to include (space separated): ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append(names, strings.Fields(answer)...), nil
+}
+
+// prompt writes the question to STDERR and reads a line of input. Returns an empty answer at the end of input.
+func (s *State) prompt(in *bufio.Reader, format string, args ...interface{}) (string, error) {
+	fmt.Fprintf(s.Stderr, format, args...)
+
+	line, err := in.ReadString('\n')
+	if err == io.EOF {
+		fmt.Fprintln(s.Stderr)
+		err = nil
+	}
+
+	return strings.TrimSpace(line), err
+}
@@ -0,0 +1,129 @@
+package state
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInitCommand_Run(t *testing.T) {
+	cases := []struct {
+		name      string
+		args      []string
+		stdin     string
+		existing  bool
+		templates []string
+		exitcode  ExitStatus
+	}{
+		{
+			"yes",
+			[]string{"init", "-yes", "Linux"},
+			"",
+			false,
+			[]string{"Go", "VisualStudioCode", "macOS", "Linux"},
+			0,
+		},
+		{
+			"interactive",
+			[]string{"init"},
+			"n\ny\nno\nLinux Windows\n",
+			false,
+			[]string{"VisualStudioCode", "Linux", "Windows"},
+			0,
+		},
+		{
+			"end of input accepts detected",
+			[]string{"init"},
+			"n\n",
+			false,
+			[]string{"VisualStudioCode", "macOS"},
+			0,
+		},
+		{
+			"existing",
+			[]string{"init", "-yes"},
+			"",
+			true,
+			nil,
+			1,
+		},
+		{
+			"force",
+			[]string{"init", "-yes", "-force", "go"},
+			"",
+			true,
+			[]string{"Go", "VisualStudioCode", "macOS"},
+			0,
+		},
+		{
+			"missing",
+			[]string{"init", "-yes", "Missing"},
+			"",
+			false,
+			nil,
+			1,
+		},
+	}
+
+	t.Parallel()
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			dir, cleanup := tempDir(t)
+			defer cleanup()
+
+			writeFile(t, filepath.Join(dir, "go.mod"), "module example.com/app\n")
+			writeFile(t, filepath.Join(dir, ".DS_Store"), "")
+			require.NoError(t, os.Mkdir(filepath.Join(dir, ".vscode"), 0755))
+			if tt.existing {
+				writeFile(t, filepath.Join(dir, ".gitignore"), "*.log\n")
+			}
+
+			s := newState(nil, "valid", append([]string{"-C", dir}, tt.args...)...)
+			defer s.Logger().ShutdownLoggers()
+			s.Stdin = bytes.NewBufferString(tt.stdin)
+			require.NoError(t, s.ParseArguments())
+
+			cmd, err := s.Command()
+			require.NoError(t, err)
+			assert.Equal(t, "init", cmd.GetName())
+			assert.Equal(t, tt.exitcode, cmd.Run())
+
+			buf, err := ioutil.ReadFile(filepath.Join(dir, ".gitignore"))
+			if tt.exitcode != 0 {
+				if tt.existing {
+					assert.Equal(t, "*.log\n", string(buf))
+				} else {
+					assert.True(t, os.IsNotExist(err))
+				}
+				return
+			}
+			require.NoError(t, err)
+
+			g := ParseGitignoreString(string(buf))
+			blocks, err := g.Blocks()
+			require.NoError(t, err)
+			var names []string
+			for _, b := range blocks {
+				names = append(names, b.Name)
+			}
+			assert.Equal(t, tt.templates, names)
+			assert.Equal(t, localRules, g.Lines[len(g.Lines)-1].Text)
+
+			lock, err := s.ReadLock()
+			require.NoError(t, err)
+			require.NotNil(t, lock)
+			assert.Equal(t, "github/gitignore", lock.Repo)
+			assert.Equal(t, "56e3f5a7b2a67413a1d3e33fceb8100898015a2e", lock.Commit)
+			require.Len(t, lock.Templates, len(blocks))
+			for idx, b := range blocks {
+				assert.Equal(t, LockedTemplate{Name: b.Name, Path: b.Path, SHA: b.SHA}, lock.Templates[idx])
+			}
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// absolutePrefixes are the leading path components of patterns that look like absolute filesystem paths.
+var absolutePrefixes = []string{"home/", "Users/", "usr/", "var/", "tmp/", "etc/", "opt/", "mnt/", "Volumes/"}
+
+type lintCommand State
+
+func (c *lintCommand) GetName() string { return "lint" }
+
+// Run lints the local gitignore file, or if templates are named, analyzes them as if they were composed in order,
+// and prints the diagnostics. Returns 1 if any errors were found.
+func (c *lintCommand) Run() ExitStatus {
+	s := (*State)(c)
+	logger := s.Logger()
+
+	var (
+		diagnostics []Diagnostic
+		err         error
+	)
+
+	if len(s.templates) == 0 {
+		diagnostics, err = s.LintFile(GitignoreFile)
+	} else {
+		var sections []*Section
+		sections, err = s.fetchSections(s.templates)
+		if err == nil {
+			diagnostics = AnalyzeConflicts(NewSectionMatcher(sections).Rules())
+		}
+	}
+
+	if err != nil {
+		logger.Error(err.Error())
+		return 2
+	}
+
+	if err := s.printDiagnostics(diagnostics); err != nil {
+		logger.Error(err.Error())
+		return 2
+	}
+
+	if HasErrors(diagnostics) {
+		return 1
+	}
+
+	return 0
+}
+
+func (s *State) printDiagnostics(diagnostics []Diagnostic) error {
+	if s.format == "json" {
+		if diagnostics == nil {
+			diagnostics = []Diagnostic{}
+		}
+		enc := json.NewEncoder(s.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(diagnostics)
+	}
+
+	for _, d := range diagnostics {
+		if _, err := fmt.Fprintln(s.Stdout, d.String()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// LintFile analyzes the named gitignore file, relative to the working directory, and the files tracked by git.
+func (s *State) LintFile(name string) ([]Diagnostic, error) {
+	g, err := s.ReadGitignore(name)
+	if err != nil {
+		return nil, err
+	}
+
+	tracked, err := s.TrackedFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	return LintGitignore(g, name, tracked)
+}
+
+// LintGitignore analyzes a gitignore file for unreachable negations, duplicate and shadowed rules, patterns with
+// significant trailing whitespace, patterns that look like absolute paths and rules that match tracked files.
+// Diagnostics are sorted by line.
+func LintGitignore(g *Gitignore, source string, tracked []string) ([]Diagnostic, error) {
+	m, err := NewGitignoreMatcher(g, source)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := m.Rules()
+	rv := AnalyzeConflicts(rules)
+
+	var (
+		// first maps the canonical form of each pattern to the index of its last occurrence that isn't a duplicate
+		first     = make(map[string]int)
+		negations = make([]int, len(rules))
+	)
+
+	for i, r := range rules {
+		p := r.Pattern
+		if i > 0 {
+			negations[i] = negations[i-1]
+		}
+		if p.Negated {
+			negations[i]++
+		}
+
+		// as in Dedupe, a negation between the two rules may re-include paths that only the later one ignores again
+		key := p.Canonical()
+		if j, ok := first[key]; ok && negations[i-1] == negations[j] {
+			rv = append(rv, r.diagnostic(SeverityWarning, "duplicate", "%s duplicates line %d", r.describe(), rules[j].Number))
+		} else {
+			first[key] = i
+			if !p.Negated {
+				if earlier := shadowedBy(rules[:i], negations, r); earlier != nil {
+					rv = append(rv, r.diagnostic(
+						SeverityWarning,
+						"shadowed",
+						"%s is already covered by %s",
+						r.describe(), earlier.describe(),
+					))
+				}
+			}
+		}
+
+		if strings.HasSuffix(p.Glob, "\t") || strings.HasSuffix(p.Glob, "\\ ") {
+			rv = append(rv, r.diagnostic(
+				SeverityWarning,
+				"trailing-whitespace",
+				"%s ends with whitespace that is part of the pattern",
+				r.describe(),
+			))
+		}
+
+		if looksAbsolute(p) {
+			rv = append(rv, r.diagnostic(
+				SeverityWarning,
+				"absolute-path",
+				"%s looks like an absolute path but patterns are relative to the directory of the gitignore file",
+				r.describe(),
+			))
+		}
+	}
+
+	matched := make(map[*Rule][]string)
+	for _, name := range tracked {
+		if r := m.Match(name, false); r != nil && !r.Pattern.Negated {
+			matched[r] = append(matched[r], name)
+		}
+	}
+	for _, r := range rules {
+		if files := matched[r]; len(files) > 0 {
+			message := "%s matches tracked file %s which git will not ignore"
+			if len(files) > 1 {
+				message = fmt.Sprintf("%%s matches tracked file %%s and %d others which git will not ignore", len(files)-1)
+			}
+			rv = append(rv, r.diagnostic(SeverityWarning, "tracked", message, r.describe(), files[0]))
+		}
+	}
+
+	sort.SliceStable(rv, func(i, j int) bool { return rv[i].Line < rv[j].Line })
+	return rv, nil
+}
+
+// shadowedBy returns an earlier rule that already ignores everything the rule matches, as judged by sample paths,
+// provided no negation appears between them.
+func shadowedBy(earlier []*Rule, negations []int, r *Rule) *Rule {
+	samples := []string{r.Pattern.sample("x"), r.Pattern.sample("q7")}
+	for _, sample := range samples {
+		if sample == "" || !r.Matches(sample, r.Pattern.DirOnly) {
+			return nil
+		}
+	}
+
+	for i := len(earlier) - 1; i >= 0; i-- {
+		e := earlier[i]
+		if negations[i] != negations[len(earlier)] {
+			// a negation between the rules may have re-included something the later rule ignores again
+			return nil
+		}
+		if e.Pattern.Negated {
+			continue
+		}
+
+		only := &Matcher{rules: []*Rule{e}}
+		covered := true
+		for _, sample := range samples {
+			if !only.Ignored(sample, r.Pattern.DirOnly) {
+				covered = false
+			}
+		}
+		if covered {
+			return e
+		}
+	}
+
+	return nil
+}
+
+// looksAbsolute reports whether the pattern looks like an absolute filesystem path.
+func looksAbsolute(p *Pattern) bool {
+	glob := p.Glob
+	if strings.HasPrefix(glob, "~/") {
+		return true
+	}
+
+	if len(glob) > 2 && glob[1] == ':' && (glob[2] == '\\' || glob[2] == '/') {
+		return true
+	}
+
+	if p.Anchored {
+		for _, prefix := range absolutePrefixes {
+			if strings.HasPrefix(glob, prefix) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,135 @@
+package state
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLintCommand_Run(t *testing.T) {
+	cases := []struct {
+		name     string
+		args     []string
+		stdout   string
+		exitcode ExitStatus
+	}{
+		{
+			"templates",
+			[]string{"lint", "Linux", "VisualStudioCode"},
+			"",
+			0,
+		},
+		{
+			"json",
+			[]string{"-format", "json", "lint", "Go"},
+			"[]\n",
+			0,
+		},
+	}
+
+	t.Parallel()
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			s := newState(nil, "valid", append([]string{"-timeout=0"}, tt.args...)...)
+			defer s.Logger().ShutdownLoggers()
+			require.NoError(t, s.ParseArguments())
+
+			cmd, err := s.Command()
+			require.NoError(t, err)
+			assert.Equal(t, "lint", cmd.GetName())
+			assert.Equal(t, tt.exitcode, cmd.Run())
+			assert.Equal(t, tt.stdout, s.Stdout.(*bytes.Buffer).String())
+		})
+	}
+}
+
+func TestLintGitignore(t *testing.T) {
+	g := ParseGitignoreString(chain(
+		"build/\n",
+		"!build/keep.txt\n",
+		"*.log\n",
+		"debug.log\n",
+		"**/*.log\n",
+		"notes.txt\t\n",
+		"/home/demosdemon/scratch\n",
+		"*.env\n",
+		"# BEGIN update-gitignore: Go\n",
+		"*.exe\n",
+		"# END update-gitignore: Go\n",
+	))
+
+	diagnostics, err := LintGitignore(g, ".gitignore", []string{"config.env", "prod.env", "main.go"})
+	require.NoError(t, err)
+
+	codes := make([]string, len(diagnostics))
+	for idx, d := range diagnostics {
+		codes[idx] = fmt.Sprintf("%d:%s:%s", d.Line, d.Severity, d.Code)
+	}
+	assert.Equal(t, []string{
+		"2:error:unreachable-negation",
+		"4:warning:shadowed",
+		"5:warning:duplicate",
+		"6:warning:trailing-whitespace",
+		"7:warning:absolute-path",
+		"8:warning:tracked",
+	}, codes)
+	assert.Equal(t, `.gitignore:8: warning: "*.env" (.gitignore:8) matches tracked file config.env and 1 others which git will not ignore`, diagnostics[5].String())
+
+	_, err = LintGitignore(ParseGitignoreString("# BEGIN update-gitignore: Go\n"), ".gitignore", nil)
+	assert.EqualError(t, err, ".gitignore: line 1: block Go is not terminated")
+}
+
+func TestLintGitignore_DuplicateAfterNegation(t *testing.T) {
+	t.Parallel()
+
+	// line 3 ignores foo again after line 2 re-includes it, so only line 4 is redundant
+	diagnostics, err := LintGitignore(ParseGitignoreString("foo\n!foo\nfoo\nfoo\n"), ".gitignore", nil)
+	require.NoError(t, err)
+
+	codes := make([]string, len(diagnostics))
+	for idx, d := range diagnostics {
+		codes[idx] = fmt.Sprintf("%d:%s:%s", d.Line, d.Severity, d.Code)
+	}
+	assert.Equal(t, []string{"4:warning:duplicate"}, codes)
+	assert.Contains(t, diagnostics[0].String(), "duplicates line 3")
+}
+
+func TestLintCommand_Local(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	writeFile(t, filepath.Join(dir, ".gitignore"), "*.log\n*.log\n")
+	writeFile(t, filepath.Join(dir, "app.log"), "")
+	for _, args := range [][]string{{"init", "-q"}, {"add", "-f", ".gitignore", "app.log"}} {
+		cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+		require.NoError(t, cmd.Run())
+	}
+
+	s := newState(nil, "valid", "-C", dir, "lint")
+	defer s.Logger().ShutdownLoggers()
+	require.NoError(t, s.ParseArguments())
+
+	cmd, err := s.Command()
+	require.NoError(t, err)
+	assert.Equal(t, ExitStatus(0), cmd.Run())
+	assert.Equal(t, chain(
+		".gitignore:2: warning: \"*.log\" (.gitignore:2) duplicates line 1\n",
+		".gitignore:2: warning: \"*.log\" (.gitignore:2) matches tracked file app.log which git will not ignore\n",
+	), s.Stdout.(*bytes.Buffer).String())
+
+	writeFile(t, filepath.Join(dir, ".gitignore"), "out/\n!out/keep\n")
+	s = newState(nil, "valid", "-C", dir, "-format", "json", "lint")
+	defer s.Logger().ShutdownLoggers()
+	require.NoError(t, s.ParseArguments())
+
+	cmd, err = s.Command()
+	require.NoError(t, err)
+	assert.Equal(t, ExitStatus(1), cmd.Run())
+	assert.Contains(t, s.Stdout.(*bytes.Buffer).String(), `"code": "unreachable-negation"`)
+}
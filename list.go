@@ -0,0 +1,184 @@
+package state
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ListVersion is the version of the schema of the json and ndjson output of list. It changes only when a field is
+// removed or its meaning changes.
+const ListVersion = 1
+
+type (
+	listCommand State
+
+	// ListedTemplate is a template in the json and ndjson output of list.
+	ListedTemplate struct {
+		Version int      `json:"version"`
+		Name    string   `json:"name"`
+		Path    string   `json:"path"`
+		Size    uint64   `json:"size"`
+		SHA     string   `json:"sha"`
+		Tags    []string `json:"tags"`
+		Source  Source   `json:"source"`
+	}
+
+	// Source is the repository and commit a template was listed from.
+	Source struct {
+		Repo   string `json:"repo"`
+		Ref    string `json:"ref"`
+		Commit string `json:"commit"`
+	}
+)
+
+func (c *listCommand) GetName() string { return "list" }
+
+// Run prints the templates matching the searches, or every template if there are none. Returns 1 if nothing
+// matches.
+func (c *listCommand) Run() ExitStatus {
+	s := (*State)(c)
+	logger := s.Logger()
+
+	cat, err := s.Catalog()
+	if err != nil {
+		logger.Error(err.Error())
+		return 1
+	}
+
+	templates := cat.Search(s.templates...)
+
+	switch {
+	case s.formatTemplate != nil:
+		err = s.writeTemplates(templates)
+	case s.format == "table":
+		err = s.writeTemplateTable(templates)
+	case s.format == "json":
+		listed := make([]*ListedTemplate, len(templates))
+		for idx, t := range templates {
+			listed[idx] = NewListedTemplate(cat, t)
+		}
+		enc := json.NewEncoder(s.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(listed)
+	case s.format == "ndjson":
+		enc := json.NewEncoder(s.Stdout)
+		for _, t := range templates {
+			if err = enc.Encode(NewListedTemplate(cat, t)); err != nil {
+				break
+			}
+		}
+	default:
+		for _, t := range templates {
+			if _, err = fmt.Fprintln(s.Stdout, strings.TrimSuffix(t.Path, Suffix)); err != nil {
+				break
+			}
+		}
+	}
+
+	if err != nil {
+		logger.Error(err.Error())
+		return 1
+	}
+
+	if len(templates) == 0 {
+		logger.Errorf("no templates match %s", strings.Join(s.templates, " "))
+		return 1
+	}
+
+	return 0
+}
+
+// writeTemplates executes the -format template for each template, ending each with a newline.
+func (s *State) writeTemplates(templates []*Template) error {
+	var buf bytes.Buffer
+	for _, t := range templates {
+		buf.Reset()
+		if err := s.formatTemplate.Execute(&buf, t); err != nil {
+			return err
+		}
+		if !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+			buf.WriteByte('\n')
+		}
+		if _, err := buf.WriteTo(s.Stdout); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeTemplateTable prints the templates in columns, padded and fit to the width of the terminal only if STDOUT is
+// one so the output stays easy to parse.
+func (s *State) writeTemplateTable(templates []*Template) error {
+	rows := [][]string{{"NAME", "PATH", "SIZE", "SHA", "TAGS"}}
+	for _, t := range templates {
+		rows = append(rows, []string{t.Name, t.Path, humanSize(t.Size), shortSHA(t.SHA), strings.Join(t.Tags, ",")})
+	}
+
+	if !isTerminal(s.Stdout) {
+		return WriteTable(s.Stdout, rows, false, 0)
+	}
+	return WriteTable(s.Stdout, rows, true, s.terminalWidth(s.Stdout))
+}
+
+// humanSize formats a number of bytes with binary units, e.g. 1.5 KiB.
+func humanSize(size uint64) string {
+	if size < 1024 {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	value, unit := float64(size)/1024, 0
+	for value >= 1024 && unit < 3 {
+		value /= 1024
+		unit++
+	}
+	return fmt.Sprintf("%.1f %ciB", value, "KMGT"[unit])
+}
+
+// shortSHA abbreviates the object name like git does by default.
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
+
+// NewListedTemplate describes the template from the catalog with the current schema version.
+func NewListedTemplate(cat *Catalog, t *Template) *ListedTemplate {
+	tags := t.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+
+	return &ListedTemplate{
+		Version: ListVersion,
+		Name:    t.Name,
+		Path:    t.Path,
+		Size:    t.Size,
+		SHA:     t.SHA,
+		Tags:    tags,
+		Source:  Source{Repo: cat.Repo, Ref: cat.Ref, Commit: cat.Commit},
+	}
+}
+
+// Search returns the templates whose name or path contains any of the searches, ignoring case, in catalog order.
+// Every template is returned if there are no searches.
+func (cat *Catalog) Search(searches ...string) []*Template {
+	if len(searches) == 0 {
+		return cat.Templates
+	}
+
+	var rv []*Template
+	for _, t := range cat.Templates {
+		path := strings.ToLower(t.Path)
+		for _, search := range searches {
+			if strings.Contains(path, strings.ToLower(search)) {
+				rv = append(rv, t)
+				break
+			}
+		}
+	}
+
+	return rv
+}
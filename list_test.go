@@ -0,0 +1,199 @@
+package state
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const listSource = `"source":{"repo":"github/gitignore","ref":"master","commit":"56e3f5a7b2a67413a1d3e33fceb8100898015a2e"}`
+
+func TestListCommand_Run(t *testing.T) {
+	cases := []struct {
+		name     string
+		args     []string
+		stdout   string
+		exitcode ExitStatus
+	}{
+		{
+			"text",
+			[]string{"list", "macos", "VISUALSTUDIO", "hugo"},
+			"community/Golang/Hugo\nGlobal/macOS\nGlobal/VisualStudioCode\nVisualStudio\n",
+			0,
+		},
+		{
+			"ndjson",
+			[]string{"-format", "ndjson", "list", "hugo", "Global/macOS"},
+			`{"version":1,"name":"Hugo","path":"community/Golang/Hugo.gitignore","size":207,` +
+				`"sha":"3718de7bf338031efa2eeb65eec265e25fc32393","tags":["community","Golang"],` + listSource + "}\n" +
+				`{"version":1,"name":"macOS","path":"Global/macOS.gitignore","size":402,` +
+				`"sha":"135767fc075ec33f7f9966fb28968113e32b697e","tags":["Global"],` + listSource + "}\n",
+			0,
+		},
+		{
+			"json",
+			[]string{"-format", "json", "list", "ada.gitignore"},
+			"[\n  {\n" +
+				"    \"version\": 1,\n" +
+				"    \"name\": \"Ada\",\n" +
+				"    \"path\": \"Ada.gitignore\",\n" +
+				"    \"size\": 51,\n" +
+				"    \"sha\": \"b4d703968a488445345202ef8d45a35cc802aa03\",\n" +
+				"    \"tags\": [],\n" +
+				"    \"source\": {\n" +
+				"      \"repo\": \"github/gitignore\",\n" +
+				"      \"ref\": \"master\",\n" +
+				"      \"commit\": \"56e3f5a7b2a67413a1d3e33fceb8100898015a2e\"\n" +
+				"    }\n" +
+				"  }\n]\n",
+			0,
+		},
+		{
+			"table",
+			[]string{"-format", "table", "list", "hugo", "ada.gitignore"},
+			"NAME\tPATH\tSIZE\tSHA\tTAGS\n" +
+				"Ada\tAda.gitignore\t51 B\tb4d7039\t\n" +
+				"Hugo\tcommunity/Golang/Hugo.gitignore\t207 B\t3718de7\tcommunity,Golang\n",
+			0,
+		},
+		{
+			"template",
+			[]string{"-format", `{{.Name}}\t{{.Size}}`, "list", "hugo", "ada.gitignore"},
+			"Ada\t51\nHugo\t207\n",
+			0,
+		},
+		{
+			"template with newline",
+			[]string{"-format", "{{range .Tags}}{{.}}\n{{end}}", "list", "hugo"},
+			"community\nGolang\n",
+			0,
+		},
+		{
+			"template error",
+			[]string{"-format", "{{.Missing}}", "list", "hugo"},
+			"",
+			1,
+		},
+		{
+			"no match",
+			[]string{"list", "nonexistent"},
+			"",
+			1,
+		},
+		{
+			"json no match",
+			[]string{"-format", "json", "list", "nonexistent"},
+			"[]\n",
+			1,
+		},
+	}
+
+	t.Parallel()
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			s := newState(nil, "valid", tt.args...)
+			defer s.Logger().ShutdownLoggers()
+			require.NoError(t, s.ParseArguments())
+
+			cmd, err := s.Command()
+			require.NoError(t, err)
+			assert.Equal(t, "list", cmd.GetName())
+			assert.Equal(t, tt.exitcode, cmd.Run())
+			assert.Equal(t, tt.stdout, s.Stdout.(*bytes.Buffer).String())
+		})
+	}
+}
+
+func TestCatalog_Search(t *testing.T) {
+	cat := &Catalog{Templates: []*Template{
+		{Name: "Go", Path: "Go.gitignore"},
+		{Name: "Hugo", Path: "community/Golang/Hugo.gitignore", Tags: []string{"community", "Golang"}},
+		{Name: "Node", Path: "Node.gitignore"},
+	}}
+
+	assert.Equal(t, cat.Templates, cat.Search())
+	assert.Equal(t, cat.Templates[:2], cat.Search("go"))
+	assert.Equal(t, cat.Templates[1:], cat.Search("node", "GOLANG"))
+	assert.Empty(t, cat.Search("python"))
+}
+
+func TestState_SetFormat(t *testing.T) {
+	cases := []struct {
+		name     string
+		format   string
+		err      *string
+		template bool
+	}{
+		{"text", "text", nil, false},
+		{"table", "table", nil, false},
+		{"template", "{{.Name}}", nil, true},
+		{"unknown", "xml", strptr("invalid format"), false},
+		{"invalid template", "{{.Name", strptr("invalid format: template: format:1: unclosed action"), false},
+	}
+
+	t.Parallel()
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			s := new(State)
+			errEquals(t, tt.err, s.SetFormat(tt.format))
+			assert.Equal(t, tt.template, s.formatTemplate != nil)
+		})
+	}
+}
+
+func TestWriteTable(t *testing.T) {
+	rows := [][]string{{"NAME", "PATH", "TAGS"}, {"Hugo", "community/Golang/Hugo.gitignore", "community,Golang"}, {"Go", "Go.gitignore", ""}}
+
+	cases := []struct {
+		name   string
+		pad    bool
+		width  int
+		output string
+	}{
+		{
+			"tabs",
+			false,
+			10,
+			"NAME\tPATH\tTAGS\nHugo\tcommunity/Golang/Hugo.gitignore\tcommunity,Golang\nGo\tGo.gitignore\t\n",
+		},
+		{
+			"padded",
+			true,
+			0,
+			"NAME  PATH                             TAGS\n" +
+				"Hugo  community/Golang/Hugo.gitignore  community,Golang\n" +
+				"Go    Go.gitignore\n",
+		},
+		{
+			"truncated",
+			true,
+			24,
+			"NAME  PATH…\n" +
+				"Hugo  community/Golang/…\n" +
+				"Go    Go.gitignore\n",
+		},
+	}
+
+	t.Parallel()
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, WriteTable(&buf, rows, tt.pad, tt.width))
+			assert.Equal(t, tt.output, buf.String())
+		})
+	}
+}
+
+func TestHumanSize(t *testing.T) {
+	assert.Equal(t, "0 B", humanSize(0))
+	assert.Equal(t, "1023 B", humanSize(1023))
+	assert.Equal(t, "1.0 KiB", humanSize(1024))
+	assert.Equal(t, "1.7 KiB", humanSize(1745))
+	assert.Equal(t, "3.0 MiB", humanSize(3<<20))
+	assert.Equal(t, "2048.0 TiB", humanSize(2<<50))
+}
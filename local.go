@@ -0,0 +1,31 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// GitignoreFile is the name of the gitignore file managed in the working directory.
+const GitignoreFile = ".gitignore"
+
+// Path joins the elements to the working directory, unless the first is an absolute path.
+func (s *State) Path(elem ...string) string {
+	if len(elem) > 0 && filepath.IsAbs(elem[0]) {
+		return filepath.Join(elem...)
+	}
+	return filepath.Join(append([]string{s.dir}, elem...)...)
+}
+
+// ReadGitignore parses the named file relative to the working directory. A missing file is treated as empty.
+func (s *State) ReadGitignore(name string) (*Gitignore, error) {
+	fp, err := os.Open(s.Path(name))
+	if os.IsNotExist(err) {
+		return new(Gitignore), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer fp.Close()
+
+	return ParseGitignore(fp)
+}
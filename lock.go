@@ -0,0 +1,107 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+)
+
+const (
+	// LockFile is the name of the file recording the templates used by the managed blocks of the gitignore file.
+	LockFile = ".gitignore.lock"
+	// LockVersion is the version of the lockfile format.
+	LockVersion = 1
+)
+
+type (
+	// Lock records the upstream state of each managed block so updates can be reviewed and reproduced.
+	Lock struct {
+		Version   int              `json:"version"`
+		Repo      string           `json:"repo"`
+		Ref       string           `json:"ref"`
+		Commit    string           `json:"commit"`
+		Templates []LockedTemplate `json:"templates"`
+	}
+
+	// LockedTemplate is the template used by a managed block.
+	LockedTemplate struct {
+		Name string `json:"name"`
+		Repo string `json:"repo,omitempty"`
+		Path string `json:"path"`
+		SHA  string `json:"sha"`
+	}
+)
+
+// NewLock records the managed blocks of the gitignore file as fetched from the catalog.
+func NewLock(cat *Catalog, g *Gitignore) (*Lock, error) {
+	blocks, err := g.Blocks()
+	if err != nil {
+		return nil, err
+	}
+
+	lock := &Lock{
+		Version:   LockVersion,
+		Repo:      cat.Repo,
+		Ref:       cat.Ref,
+		Commit:    cat.Commit,
+		Templates: make([]LockedTemplate, 0, len(blocks)),
+	}
+
+	for _, b := range blocks {
+		if b.Path == "" {
+			// not from a template
+			continue
+		}
+
+		t := LockedTemplate{Name: b.Name, Path: b.Path, SHA: b.SHA}
+		if b.Repo != cat.Repo {
+			t.Repo = b.Repo
+		}
+		lock.Templates = append(lock.Templates, t)
+	}
+
+	return lock, nil
+}
+
+// Find returns the locked template with the given block name, or nil.
+func (l *Lock) Find(name string) *LockedTemplate {
+	for idx := range l.Templates {
+		if l.Templates[idx].Name == name {
+			return &l.Templates[idx]
+		}
+	}
+	return nil
+}
+
+// ReadLock reads the lockfile in the working directory. Returns nil without error if there is no lockfile.
+func (s *State) ReadLock() (*Lock, error) {
+	buf, err := ioutil.ReadFile(s.Path(LockFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	lock := new(Lock)
+	if err := json.Unmarshal(buf, lock); err != nil {
+		return nil, fmt.Errorf("%s: %v", LockFile, err)
+	}
+
+	if lock.Version != LockVersion {
+		return nil, fmt.Errorf("%s: unsupported version %d", LockFile, lock.Version)
+	}
+
+	return lock, nil
+}
+
+// WriteLock writes the lockfile in the working directory.
+func (s *State) WriteLock(lock *Lock) error {
+	buf, err := json.MarshalIndent(lock, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return s.writeFile(LockFile, append(buf, '\n'), 0644)
+}
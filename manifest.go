@@ -0,0 +1,184 @@
+package state
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// LocalBlock is the name of the managed block holding the local patterns listed in a manifest.
+const LocalBlock = "local"
+
+// ManifestFiles are the names of the manifest files looked for in the working directory, in order of preference.
+var ManifestFiles = []string{".gitignore.toml", ".gitignore.yaml", ".gitignore.yml"}
+
+type (
+	// Manifest declares the templates a project's gitignore file is generated from:
+	//
+	//	output = ".gitignore"
+	//	templates = ["Go", "Global/macOS", "work:Terraform"]
+	//	local = ["/dist/", "*.env"]
+	//
+	//	[[sources]]
+	//	name = "work"
+	//	repo = "acme/gitignore"
+	//	ref = "main"
+	//
+	// Templates are taken from the configured repository unless prefixed with the name of a source and a colon.
+	Manifest struct {
+		Output    string           `toml:"output" yaml:"output"`
+		Sources   []ManifestSource `toml:"sources" yaml:"sources"`
+		Templates []string         `toml:"templates" yaml:"templates"`
+		Local     []string         `toml:"local" yaml:"local"`
+	}
+
+	// ManifestSource is a template repository named in a manifest.
+	ManifestSource struct {
+		Name string `toml:"name" yaml:"name"`
+		Repo string `toml:"repo" yaml:"repo"`
+		Ref  string `toml:"ref" yaml:"ref"`
+	}
+)
+
+// ParseManifest decodes a manifest, choosing TOML or YAML by the extension of the file name, and checks that every
+// template refers to a declared source.
+func ParseManifest(name string, data []byte) (*Manifest, error) {
+	m := new(Manifest)
+
+	var err error
+	switch filepath.Ext(name) {
+	case ".toml":
+		var md toml.MetaData
+		if md, err = toml.Decode(string(data), m); err == nil {
+			if undecoded := md.Undecoded(); len(undecoded) > 0 {
+				err = fmt.Errorf("unknown key %s", undecoded[0])
+			}
+		}
+	case ".yaml", ".yml":
+		err = yaml.UnmarshalStrict(data, m)
+	default:
+		err = fmt.Errorf("unrecognized manifest format")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+
+	if m.Output == "" {
+		m.Output = GitignoreFile
+	}
+
+	seen := make(map[string]bool)
+	for _, src := range m.Sources {
+		switch {
+		case src.Name == "" || src.Repo == "":
+			return nil, fmt.Errorf("%s: sources need a name and a repo", name)
+		case seen[src.Name]:
+			return nil, fmt.Errorf("%s: source %s is declared more than once", name, src.Name)
+		}
+		seen[src.Name] = true
+	}
+
+	for _, t := range m.Templates {
+		if source, _ := splitTemplate(t); source != "" && !seen[source] {
+			return nil, fmt.Errorf("%s: template %s uses undeclared source %s", name, t, source)
+		}
+	}
+
+	return m, nil
+}
+
+// ReadManifest reads the first manifest found in the working directory. Returns nil without error if there is none.
+func (s *State) ReadManifest() (*Manifest, error) {
+	for _, name := range ManifestFiles {
+		data, err := ioutil.ReadFile(s.Path(name))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		return ParseManifest(name, data)
+	}
+
+	return nil, nil
+}
+
+// source returns the declared source with the name, or the configured repository if name is empty.
+func (m *Manifest) source(s *State, name string) ManifestSource {
+	for _, src := range m.Sources {
+		if src.Name == name {
+			if src.Ref == "" {
+				src.Ref = DefaultRef
+			}
+			return src
+		}
+	}
+	return ManifestSource{Repo: s.repo, Ref: DefaultRef}
+}
+
+// splitTemplate splits a manifest template into its source and template names.
+func splitTemplate(t string) (string, string) {
+	if idx := strings.Index(t, ":"); idx >= 0 {
+		return t[:idx], t[idx+1:]
+	}
+	return "", t
+}
+
+// PlanManifest computes the gitignore file declared by the manifest. Blocks for templates no longer listed are
+// removed and the local patterns are kept in a block of their own.
+func (s *State) PlanManifest(m *Manifest) (*Update, error) {
+	return s.planManifest(m, m.Output, make(map[string]*Catalog))
+}
+
+// planManifest computes the named file, relative to the working directory, from the templates and local patterns
+// of the manifest. Catalogs are taken from, and added to, the catalogs fetched so far.
+func (s *State) planManifest(m *Manifest, name string, catalogs map[string]*Catalog) (*Update, error) {
+	var (
+		primary  *Catalog
+		sections []*Section
+	)
+
+	for _, t := range m.Templates {
+		source, name := splitTemplate(t)
+
+		src := m.source(s, source)
+		cat, err := s.catalogFrom(catalogs, src.Repo, src.Ref)
+		if err != nil {
+			return nil, err
+		}
+		if primary == nil {
+			primary = cat
+		}
+
+		templates, err := cat.Resolve(name)
+		if err != nil {
+			return nil, err
+		}
+
+		secs, err := s.Sections(cat, templates)
+		if err != nil {
+			return nil, err
+		}
+		sections = append(sections, secs...)
+	}
+
+	if len(m.Local) > 0 {
+		sections = append(sections, &Section{
+			Template: &Template{Name: LocalBlock},
+			Content:  ParseGitignoreString(strings.Join(m.Local, "\n") + "\n"),
+		})
+	}
+
+	if primary == nil {
+		src := m.source(s, "")
+		primary = &Catalog{Repo: src.Repo, Ref: src.Ref}
+	}
+
+	return s.plan(name, primary, sections, true)
+}
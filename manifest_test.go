@@ -0,0 +1,163 @@
+package state
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseManifest(t *testing.T) {
+	cases := []struct {
+		name     string
+		file     string
+		data     string
+		manifest *Manifest
+		err      *string
+	}{
+		{
+			"toml",
+			".gitignore.toml",
+			chain(
+				"templates = [\"Go\", \"work:Terraform\"]\n",
+				"local = [\"/dist/\"]\n",
+				"\n",
+				"[[sources]]\n",
+				"name = \"work\"\n",
+				"repo = \"acme/gitignore\"\n",
+				"ref = \"main\"\n",
+			),
+			&Manifest{
+				Output:    ".gitignore",
+				Sources:   []ManifestSource{{Name: "work", Repo: "acme/gitignore", Ref: "main"}},
+				Templates: []string{"Go", "work:Terraform"},
+				Local:     []string{"/dist/"},
+			},
+			nil,
+		},
+		{
+			"yaml",
+			".gitignore.yaml",
+			chain(
+				"output: sub/.gitignore\n",
+				"templates:\n",
+				"  - Global/macOS\n",
+			),
+			&Manifest{
+				Output:    "sub/.gitignore",
+				Templates: []string{"Global/macOS"},
+			},
+			nil,
+		},
+		{
+			"unknown field",
+			".gitignore.yml",
+			"template: [Go]\n",
+			nil,
+			strptr(".gitignore.yml: yaml: unmarshal errors:\n  line 1: field template not found in type state.Manifest"),
+		},
+		{
+			"unknown toml key",
+			".gitignore.toml",
+			"templaets = [\"Go\"]\n",
+			nil,
+			strptr(".gitignore.toml: unknown key templaets"),
+		},
+		{
+			"unknown toml source key",
+			".gitignore.toml",
+			"templates = [\"Go\"]\n\n[[sources]]\nname = \"work\"\nrepo = \"acme/gitignore\"\nbranch = \"main\"\n",
+			nil,
+			strptr(".gitignore.toml: unknown key sources.branch"),
+		},
+		{
+			"undeclared source",
+			".gitignore.toml",
+			"templates = [\"work:Go\"]\n",
+			nil,
+			strptr(".gitignore.toml: template work:Go uses undeclared source work"),
+		},
+		{
+			"duplicate source",
+			".gitignore.yaml",
+			"sources:\n  - {name: a, repo: a/b}\n  - {name: a, repo: c/d}\n",
+			nil,
+			strptr(".gitignore.yaml: source a is declared more than once"),
+		},
+		{
+			"source without repo",
+			".gitignore.yaml",
+			"sources:\n  - {name: a}\n",
+			nil,
+			strptr(".gitignore.yaml: sources need a name and a repo"),
+		},
+	}
+
+	t.Parallel()
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := ParseManifest(tt.file, []byte(tt.data))
+			errEquals(t, tt.err, err)
+			assert.Equal(t, tt.manifest, m)
+		})
+	}
+}
+
+func TestUpdateCommand_Manifest(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	writeFile(t, filepath.Join(dir, ".gitignore.yaml"), chain(
+		"sources:\n",
+		"  - name: upstream\n",
+		"    repo: github/gitignore\n",
+		"templates:\n",
+		"  - upstream:Linux\n",
+		"  - Global/VisualStudioCode\n",
+		"local:\n",
+		"  - \"*.env\"\n",
+	))
+	writeFile(t, filepath.Join(dir, ".gitignore"), chain(
+		"/secrets\n",
+		"\n",
+		block("Go", "*.exe\n"),
+		"\n",
+		staleVSCode,
+	))
+
+	s := newState(nil, "valid", "-C", dir, "update")
+	require.NoError(t, s.ParseArguments())
+
+	cmd, err := s.Command()
+	require.NoError(t, err)
+	assert.Equal(t, ExitStatus(0), cmd.Run())
+	require.NoError(t, s.Logger().ShutdownLoggers())
+	assert.Contains(t, s.Stderr.(*bytes.Buffer).String(), "removing Go")
+
+	buf, err := ioutil.ReadFile(filepath.Join(dir, ".gitignore"))
+	require.NoError(t, err)
+
+	g := ParseGitignoreString(string(buf))
+	blocks, err := g.Blocks()
+	require.NoError(t, err)
+	require.Len(t, blocks, 3)
+	assert.Equal(t, "VisualStudioCode", blocks[0].Name)
+	assert.Equal(t, "0511e2b51f0d42d1dff69f4ed5df03c6649ca356", blocks[0].SHA)
+	assert.Equal(t, "Linux", blocks[1].Name)
+	assert.Equal(t, "# BEGIN update-gitignore: local", g.Lines[blocks[2].Begin].Text)
+	assert.Equal(t, []string{"*.env"}, g.texts()[blocks[2].Begin+1:blocks[2].End])
+	assert.Equal(t, "/secrets", g.Lines[0].Text)
+
+	// regenerating again is a no-op
+	s = newState(nil, "valid", "-C", dir, "diff")
+	defer s.Logger().ShutdownLoggers()
+	require.NoError(t, s.ParseArguments())
+
+	cmd, err = s.Command()
+	require.NoError(t, err)
+	assert.Equal(t, ExitStatus(0), cmd.Run())
+}
@@ -0,0 +1,119 @@
+package state
+
+import (
+	"path"
+	"strings"
+)
+
+type (
+	// Matcher decides whether paths are ignored using git's rules.
+	Matcher struct {
+		rules []*Rule
+	}
+
+	// Rule is a pattern along with where it came from.
+	Rule struct {
+		*Line
+		// Base is the slash separated directory containing the gitignore file, relative to the root of the
+		// matcher, or the empty string for the root.
+		Base string
+		// Source names the file or template the rule came from.
+		Source string
+		// Block is the name of the managed block containing the rule, if any.
+		Block string
+	}
+)
+
+// NewMatcher returns a Matcher for the patterns in a gitignore file at the root of the tree.
+func NewMatcher(g *Gitignore, source string) *Matcher {
+	m := new(Matcher)
+	m.Add("", g, source)
+	return m
+}
+
+// Add appends the patterns of a gitignore file located in the base directory. Rules added later take precedence
+// over rules added earlier, so files in parent directories should be added before files in their children.
+func (m *Matcher) Add(base string, g *Gitignore, source string) {
+	base = strings.Trim(base, "/")
+	for _, line := range g.Patterns() {
+		m.rules = append(m.rules, &Rule{Line: line, Base: base, Source: source})
+	}
+}
+
+// AddRule appends a single rule.
+func (m *Matcher) AddRule(rule *Rule) {
+	m.rules = append(m.rules, rule)
+}
+
+// Rules returns the rules in the matcher in order of increasing precedence.
+func (m *Matcher) Rules() []*Rule {
+	return m.rules
+}
+
+// Match returns the rule that decides whether the path is ignored, or nil if no rule applies. The returned rule may
+// be negated, in which case the path is not ignored. Like git, a path inside an ignored directory is always
+// ignored because git never looks inside that directory for a negation to apply; the directory rule is returned.
+func (m *Matcher) Match(name string, isDir bool) *Rule {
+	name = strings.Trim(path.Clean("/"+name), "/")
+	if name == "" {
+		return nil
+	}
+
+	if rule := m.ExcludedParent(name); rule != nil {
+		return rule
+	}
+
+	return m.lastMatch(name, isDir)
+}
+
+// ExcludedParent returns the rule excluding the closest to the root parent directory of the path, or nil if no
+// parent directory is excluded.
+func (m *Matcher) ExcludedParent(name string) *Rule {
+	components := strings.Split(strings.Trim(name, "/"), "/")
+	for idx := 1; idx < len(components); idx++ {
+		parent := strings.Join(components[:idx], "/")
+		if rule := m.lastMatch(parent, true); rule != nil && !rule.Pattern.Negated {
+			return rule
+		}
+	}
+
+	return nil
+}
+
+// Ignored reports whether the path would be ignored.
+func (m *Matcher) Ignored(name string, isDir bool) bool {
+	rule := m.Match(name, isDir)
+	return rule != nil && !rule.Pattern.Negated
+}
+
+// lastMatch returns the last rule matching the path itself without considering its parents.
+func (m *Matcher) lastMatch(name string, isDir bool) *Rule {
+	for idx := len(m.rules) - 1; idx >= 0; idx-- {
+		if rule := m.rules[idx]; rule.Matches(name, isDir) {
+			return rule
+		}
+	}
+
+	return nil
+}
+
+// Matches reports whether the rule pattern matches the path, ignoring negation and parent directories.
+func (r *Rule) Matches(name string, isDir bool) bool {
+	p := r.Pattern
+	if p.DirOnly && !isDir {
+		return false
+	}
+
+	if r.Base != "" {
+		if !strings.HasPrefix(name, r.Base+"/") {
+			return false
+		}
+		name = name[len(r.Base)+1:]
+	}
+
+	if !p.Anchored {
+		return wildmatch(p.Glob, path.Base(name), false)
+	}
+
+	return wildmatch(p.Glob, name, true)
+}
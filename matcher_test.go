@@ -0,0 +1,204 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWildmatch(t *testing.T) {
+	// a subset of the cases from git's t3070-wildmatch.sh
+	cases := []struct {
+		pattern  string
+		text     string
+		pathname bool
+		expected bool
+	}{
+		{"foo", "foo", true, true},
+		{"bar", "foo", true, false},
+		{"???", "foo", true, true},
+		{"*f", "foo", true, false},
+		{"*", "foo", true, true},
+		{"[[:alpha:]][[:digit:]][[:upper:]]", "a1B", true, true},
+		{"[[:digit:][:upper:][:space:]]", "A", true, true},
+		{"[[:digit:][:upper:][:spaci:]]", "1", true, false},
+		{"[a-c]", "b", true, true},
+		{"[!a-c]", "b", true, false},
+		{"[^a-c]", "d", true, true},
+		{"[\\\\-^]", "]", true, true},
+		{"[\\\\-^]", "[", true, false},
+		{"[\\\\]", "\\", true, true},
+		{"[]-]", "]", true, true},
+		{"[]-]", "a", true, false},
+		{"\\*", "*", true, true},
+		{"\\*", "a", true, false},
+		{"foo*bar", "foo/baz/bar", true, false},
+		{"foo*bar", "foo/baz/bar", false, true},
+		{"foo**bar", "foo/baz/bar", true, false},
+		{"foo/**/bar", "foo/baz/bar", true, true},
+		{"foo/**/bar", "foo/bar", true, true},
+		{"foo/**/**/bar", "foo/b/a/z/bar", true, true},
+		{"**/foo", "foo", true, true},
+		{"**/foo", "XXX/foo", true, true},
+		{"**/foo", "bar/baz/foo", true, true},
+		{"*/foo", "bar/baz/foo", true, false},
+		{"**/bar*", "foo/bar/baz", true, false},
+		{"**/bar/*", "deep/foo/bar/baz", true, true},
+		{"**/bar/*", "deep/foo/bar/baz/", true, false},
+		{"**/bar/**", "deep/foo/bar/baz/", true, true},
+		{"**/bar/*", "deep/foo/bar", true, false},
+		{"**/bar/**", "deep/foo/bar/", true, true},
+		{"*/bar/**", "foo/bar/baz/x", true, true},
+		{"*/bar/**", "deep/foo/bar/baz/x", true, false},
+		{"**/bar/*/*", "deep/foo/bar/baz/x", true, true},
+		{"-*-*-*-*-*-*-12-*-*-*-m-*-*-*", "-adobe-courier-bold-o-normal--12-120-75-75-m-70-iso8859-1", true, true},
+		{"XXX/*/*/*/*/*/*/12/*/*/*/m/*/*/*", "XXX/adobe/courier/bold/o/normal//12/120/75/75/m/70/iso8859/1", true, true},
+		{"XXX/*/*/*/*/*/*/12/*/*/*/m/*/*/*", "XXX/adobe/courier/bold/o/normal//12/120/75/75/X/70/iso8859/1", true, false},
+		{"**/*a*b*g*n*t", "abcd/abcdefg/abcdefghijk/abcdefghijklmnop.txt", true, true},
+		{"**/*a*b*g*n*t", "abcd/abcdefg/abcdefghijk/abcdefghijklmnop.txtz", true, false},
+		{"[", "[", true, false},
+		{"a[", "a[", true, false},
+		{"[[:digit:]", "1", true, false},
+	}
+
+	t.Parallel()
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.pattern+" "+tt.text, func(t *testing.T) {
+			assert.Equal(t, tt.expected, wildmatch(tt.pattern, tt.text, tt.pathname))
+		})
+	}
+}
+
+func TestMatcher_Ignored(t *testing.T) {
+	// expected values were recorded with git check-ignore --no-index
+	cases := []struct {
+		gitignore string
+		path      string
+		isDir     bool
+		ignored   bool
+	}{
+		{"*.log", "a.log", false, true},
+		{"*.log", "dir/a.log", false, true},
+		{"*.log", "a.logx", false, false},
+		{"*.log", "dir.log", true, true},
+		{"/root.txt", "root.txt", false, true},
+		{"/root.txt", "sub/root.txt", false, false},
+		{"build/", "build", true, true},
+		{"build/", "src/build", true, true},
+		{"build/", "build/out.o", false, true},
+		{"build/", "build", false, false},
+		{"doc/*.txt", "doc/a.txt", false, true},
+		{"doc/*.txt", "doc/sub/a.txt", false, false},
+		{"doc/*.txt", "x/doc/a.txt", false, false},
+		{"**/logs", "logs", true, true},
+		{"**/logs", "a/logs", true, true},
+		{"**/logs", "a/b/logs", false, true},
+		{"**/logs", "logs/x", false, true},
+		{"**/logs/debug.log", "logs/debug.log", false, true},
+		{"**/logs/debug.log", "a/logs/debug.log", false, true},
+		{"**/logs/debug.log", "logs/x/debug.log", false, false},
+		{"abc/**", "abc", true, false},
+		{"abc/**", "abc/y", false, true},
+		{"abc/**", "abc/x/y", false, true},
+		{"abc/**", "abc", false, false},
+		{"a/**/b", "a/b", false, true},
+		{"a/**/b", "a/x/b", false, true},
+		{"a/**/b", "a/x/y/b", false, true},
+		{"a/**/b", "a/xb", false, false},
+		{"*.log\n!important.log", "a.log", false, true},
+		{"*.log\n!important.log", "important.log", false, false},
+		{"*.log\n!important.log", "dir/important.log", false, false},
+		{"build/\n!build/keep.txt", "build/keep.txt", false, true},
+		{"build/\n!build/keep.txt", "build/other.txt", false, true},
+		{"build/*\n!build/keep.txt", "build/keep.txt", false, false},
+		{"build/*\n!build/keep.txt", "build/other.txt", false, true},
+		{"build/*\n!build/keep.txt", "build/sub/keep.txt", false, true},
+		{"!keep.txt\n*", "keep.txt", false, true},
+		{"!keep.txt\n*", "x", false, true},
+		{"*.[oa]", "x.o", false, true},
+		{"*.[oa]", "x.a", false, true},
+		{"*.[oa]", "x.c", false, false},
+		{"*.[!oa]", "x.o", false, false},
+		{"*.[!oa]", "x.c", false, true},
+		{"file[[:digit:]].txt", "file1.txt", false, true},
+		{"file[[:digit:]].txt", "filea.txt", false, false},
+		{"\\#hash\n\\!bang", "#hash", false, true},
+		{"\\#hash\n\\!bang", "!bang", false, true},
+		{"trailing\\ ", "trailing ", false, true},
+		{"trailing\\ ", "trailing", false, false},
+		{"spaces   ", "spaces", false, true},
+		{"spaces   ", "spaces   ", false, false},
+		{"?.txt", "a.txt", false, true},
+		{"?.txt", "ab.txt", false, false},
+		{"a/*/c", "a/b/c", false, true},
+		{"a/*/c", "a/b/x/c", false, false},
+		{"foo/bar", "foo/bar", false, true},
+		{"foo/bar", "x/foo/bar", false, false},
+		{"*/foo", "a/foo", false, true},
+		{"*/foo", "a/b/foo", false, false},
+		{"*/foo", "foo", false, false},
+		{"node_modules\n!node_modules/keep", "node_modules/keep", false, true},
+		{"node_modules\n!node_modules/keep", "node_modules/x", false, true},
+		{"*\n!*/\n!*.go", "main.go", false, false},
+		{"*\n!*/\n!*.go", "pkg/x.go", false, false},
+		{"*\n!*/\n!*.go", "pkg/x.o", false, true},
+		{"*\n!*/\n!*.go", "README", false, true},
+		{"/*\n!/src/", "src", true, false},
+		{"/*\n!/src/", "src/a", false, false},
+		{"/*\n!/src/", "other", false, true},
+		{"/*\n!/src/", "lib/a", false, true},
+		{"[a-c]*.md", "a.md", false, true},
+		{"[a-c]*.md", "bx.md", false, true},
+		{"[a-c]*.md", "d.md", false, false},
+		{"*.Log", "a.log", false, false},
+		{"*.Log", "a.Log", false, true},
+	}
+
+	t.Parallel()
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.gitignore+" "+tt.path, func(t *testing.T) {
+			m := NewMatcher(ParseGitignoreString(tt.gitignore+"\n"), ".gitignore")
+			assert.Equal(t, tt.ignored, m.Ignored(tt.path, tt.isDir))
+		})
+	}
+}
+
+func TestMatcher_Match(t *testing.T) {
+	m := NewMatcher(ParseGitignoreString("# comment\n*.log\n!keep.log\nbuild/\n"), ".gitignore")
+	m.Add("sub", ParseGitignoreString("/local\n*.tmp\n"), "sub/.gitignore")
+
+	cases := []struct {
+		path   string
+		isDir  bool
+		source string
+		line   int
+	}{
+		{"debug.log", false, ".gitignore", 2},
+		{"keep.log", false, ".gitignore", 3},
+		{"build/keep.log", false, ".gitignore", 4},
+		{"sub/local", false, "sub/.gitignore", 1},
+		{"local", false, "", 0},
+		{"sub/deep/x.tmp", false, "sub/.gitignore", 2},
+		{"x.tmp", false, "", 0},
+		{"main.go", false, "", 0},
+	}
+
+	t.Parallel()
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.path, func(t *testing.T) {
+			rule := m.Match(tt.path, tt.isDir)
+			if tt.source == "" {
+				assert.Nil(t, rule)
+				return
+			}
+
+			if assert.NotNil(t, rule) {
+				assert.Equal(t, tt.source, rule.Source)
+				assert.Equal(t, tt.line, rule.Number)
+			}
+		})
+	}
+}
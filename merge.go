@@ -0,0 +1,169 @@
+package state
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"strings"
+)
+
+// The markers around the two sides of a conflict, like git's.
+const (
+	ConflictLocal    = "<<<<<<< local"
+	ConflictSplit    = "======="
+	ConflictUpstream = ">>>>>>> upstream"
+)
+
+// Conflict is a region of a managed block changed differently by local edits and upstream.
+type Conflict struct {
+	Block    string
+	Base     []string
+	Local    []string
+	Upstream []string
+}
+
+// Lines renders the conflict between git-style markers.
+func (c *Conflict) Lines() []string {
+	rv := append([]string{ConflictLocal}, c.Local...)
+	rv = append(rv, ConflictSplit)
+	rv = append(rv, c.Upstream...)
+	return append(rv, ConflictUpstream)
+}
+
+func (c *Conflict) String() string {
+	return fmt.Sprintf("conflicting edits to block %s:\n%s", c.Block, strings.Join(c.Lines(), "\n"))
+}
+
+// Merge3 applies both the changes from base to local and from base to upstream. Regions changed identically or by
+// only one side merge cleanly; the others are returned as conflicts and rendered between markers.
+func Merge3(base, local, upstream []string) ([]string, []*Conflict) {
+	var (
+		toLocal    = matches(base, local)
+		toUpstream = matches(base, upstream)
+		rv         []string
+		conflicts  []*Conflict
+		i, l, u    int
+	)
+
+	for i <= len(base) {
+		// lines unchanged on both sides
+		if i < len(base) && toLocal[i] == l && toUpstream[i] == u {
+			rv = append(rv, base[i])
+			i, l, u = i+1, l+1, u+1
+			continue
+		}
+
+		// the next base line both sides kept, or the end
+		j := i
+		for j < len(base) && (toLocal[j] < 0 || toUpstream[j] < 0) {
+			j++
+		}
+		nextLocal, nextUpstream := len(local), len(upstream)
+		if j < len(base) {
+			nextLocal, nextUpstream = toLocal[j], toUpstream[j]
+		}
+
+		var (
+			b  = base[i:j]
+			lo = local[l:nextLocal]
+			up = upstream[u:nextUpstream]
+		)
+		switch {
+		case equalLines(lo, b) || equalLines(lo, up):
+			rv = append(rv, up...)
+		case equalLines(up, b):
+			rv = append(rv, lo...)
+		default:
+			c := &Conflict{Base: b, Local: lo, Upstream: up}
+			conflicts = append(conflicts, c)
+			rv = append(rv, c.Lines()...)
+		}
+
+		if j == len(base) {
+			break
+		}
+		i, l, u = j, nextLocal, nextUpstream
+	}
+
+	return rv, conflicts
+}
+
+// matches maps each line of a to its index in b if the diff keeps it, or -1.
+func matches(a, b []string) []int {
+	rv := make([]int, len(a))
+	var i, j int
+	for _, op := range DiffLines(a, b) {
+		switch op.Kind {
+		case ' ':
+			rv[i] = j
+			i++
+			j++
+		case '-':
+			rv[i] = -1
+			i++
+		case '+':
+			j++
+		}
+	}
+	return rv
+}
+
+func equalLines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for idx := range a {
+		if a[idx] != b[idx] {
+			return false
+		}
+	}
+	return true
+}
+
+// blobSHA returns the git object name of a file with the lines, which is the sha recorded for unmodified blocks.
+func blobSHA(lines []string) string {
+	content := strings.Join(lines, "\n")
+	if len(lines) > 0 {
+		content += "\n"
+	}
+	return fmt.Sprintf("%x", sha1.Sum([]byte(fmt.Sprintf("blob %d\x00%s", len(content), content))))
+}
+
+// mergeBlock returns the lines of the block for the section keeping the local edits to the block, or nil if there
+// are none to keep. The edits are found by comparing the block with the template it was fetched from.
+func (s *State) mergeBlock(cl *Client, g *Gitignore, b *Block, sec *Section) ([]string, []*Conflict) {
+	var local, upstream []string
+	for _, line := range b.Contents(g) {
+		local = append(local, line.Text)
+	}
+	for _, line := range sec.Content.Lines {
+		upstream = append(upstream, line.Text)
+	}
+
+	if b.SHA == "" || blobSHA(local) == b.SHA || equalLines(local, upstream) {
+		return nil, nil
+	}
+
+	text, err := cl.GetTemplate(&Template{Path: b.Path, SHA: b.SHA})
+	if err != nil {
+		s.Logger().Warnf("block %s has local edits but its template %s can't be fetched to merge them: %v", b.Name, b.SHA, err)
+		return nil, nil
+	}
+
+	tmpl := ParseGitignoreString(text)
+	if c := Conversions[s.target]; c != nil {
+		// the block was written converted, so the local edits are relative to the converted template
+		tmpl, _ = c.Convert(tmpl)
+	}
+	base := tmpl.texts()
+	if equalLines(local, base) {
+		return nil, nil
+	}
+
+	lines, conflicts := Merge3(base, local, upstream)
+	for _, c := range conflicts {
+		c.Block = b.Name
+	}
+
+	header := sec.Block()
+	return append(append([]string{header.Header()}, lines...), header.Footer()), conflicts
+}
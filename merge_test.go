@@ -0,0 +1,161 @@
+package state
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const goTemplate = "# Binaries for programs and plugins\n" +
+	"*.exe\n" +
+	"*.exe~\n" +
+	"*.dll\n" +
+	"*.so\n" +
+	"*.dylib\n" +
+	"\n" +
+	"# Test binary, built with `go test -c`\n" +
+	"*.test\n" +
+	"\n" +
+	"# Output of the go coverage tool, specifically when used with LiteIDE\n" +
+	"*.out\n"
+
+func TestMerge3(t *testing.T) {
+	cases := []struct {
+		name      string
+		base      string
+		local     string
+		upstream  string
+		output    string
+		conflicts int
+	}{
+		{"unchanged", "a b c", "a b c", "a b c", "a b c", 0},
+		{"upstream", "a b c", "a b c", "a B c", "a B c", 0},
+		{"local", "a b c", "a b c d", "a b c", "a b c d", 0},
+		{"both", "a b c d e", "a B c d e", "a b c D e", "a B c D e", 0},
+		{"same change", "a b c", "a B c", "a B c", "a B c", 0},
+		{"deleted and changed elsewhere", "a b c d", "a c d", "a b c D", "a c D", 0},
+		{"inserted at both ends", "a b c", "x a b c", "a b c y", "x a b c y", 0},
+		{"conflict", "a b c", "a X c", "a Y c", "a <<<<<<< X ======= Y >>>>>>> c", 1},
+		{"conflicting appends", "a b", "a b x", "a b y", "a b <<<<<<< x ======= y >>>>>>>", 1},
+		{"deleted and changed", "a b c", "a c", "a B c", "a <<<<<<< ======= B >>>>>>> c", 1},
+		{"two conflicts", "a b c d e", "a X c Z e", "a Y c W e", "a <<<<<<< X ======= Y >>>>>>> c <<<<<<< Z ======= W >>>>>>> e", 2},
+	}
+
+	t.Parallel()
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			output, conflicts := Merge3(strings.Fields(tt.base), strings.Fields(tt.local), strings.Fields(tt.upstream))
+			short := strings.NewReplacer(ConflictLocal, "<<<<<<<", ConflictUpstream, ">>>>>>>").Replace(strings.Join(output, " "))
+			assert.Equal(t, tt.output, strings.Join(strings.Fields(short), " "))
+			assert.Len(t, conflicts, tt.conflicts)
+		})
+	}
+}
+
+func TestBlobSHA(t *testing.T) {
+	lines := strings.Split(strings.TrimSuffix(goTemplate, "\n"), "\n")
+	assert.Equal(t, "f2dd9554a12fd7acdc62e60e8eccae086f718be2", blobSHA(lines))
+	assert.Equal(t, "e69de29bb2d1d6434b8b29ae775ad8c2e48c5391", blobSHA(nil))
+}
+
+func TestUpdateCommand_Merge(t *testing.T) {
+	var (
+		linux = "*~\n\n" +
+			"# temporary files which can be created if a process still has a handle open of a deleted file\n" +
+			".fuse_hidden*\n\n" +
+			"# KDE directory preferences\n" +
+			".directory\n\n" +
+			"# Linux trash folder which might appear on any partition or disk\n" +
+			".Trash-*\n\n" +
+			"# .nfs files are created when an open file is removed but is still being accessed\n" +
+			".nfs*\n"
+		header = "# BEGIN update-gitignore: Go repo=github/gitignore path=Go.gitignore sha="
+		footer = "# END update-gitignore: Go\n"
+		// the Go block with a local edit, fetched from the current template
+		edited = header + "f2dd9554a12fd7acdc62e60e8eccae086f718be2\n" +
+			strings.Replace(goTemplate, "*.exe\n", "*.exe\n/bin/\n", 1) + footer
+		// the Go block as if fetched when the template had the content of Linux, with a conflicting edit
+		conflicting = header + "b56bf65d85583b03eeccfaa2a927084583a33e91\n" +
+			strings.Replace(linux, ".directory\n", ".directory/\n", 1) + footer
+	)
+
+	cases := []struct {
+		name     string
+		text     string
+		args     []string
+		output   string
+		stderr   string
+		exitcode ExitStatus
+	}{
+		{
+			"kept",
+			edited,
+			[]string{"update"},
+			edited,
+			"merging local edits to Go",
+			0,
+		},
+		{
+			"conflict",
+			conflicting,
+			[]string{"update"},
+			conflicting,
+			".gitignore: local edits conflict with upstream changes; resolve them or use -conflict-markers",
+			1,
+		},
+		{
+			"conflict markers",
+			conflicting,
+			[]string{"update", "-conflict-markers"},
+			// the blank lines are unchanged, so only the edited region conflicts
+			chain(
+				header, "f2dd9554a12fd7acdc62e60e8eccae086f718be2\n",
+				ConflictLocal, "\n",
+				"*~\n\n",
+				"# temporary files which can be created if a process still has a handle open of a deleted file\n",
+				".fuse_hidden*\n\n",
+				"# KDE directory preferences\n",
+				".directory/\n",
+				ConflictSplit, "\n",
+				"# Binaries for programs and plugins\n*.exe\n*.exe~\n*.dll\n*.so\n*.dylib\n",
+				ConflictUpstream, "\n",
+				"\n# Test binary, built with `go test -c`\n*.test\n\n",
+				"# Output of the go coverage tool, specifically when used with LiteIDE\n*.out\n",
+				footer,
+			),
+			".gitignore: wrote 1 conflicts between markers",
+			1,
+		},
+	}
+
+	t.Parallel()
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			dir, cleanup := tempDir(t)
+			defer cleanup()
+
+			path := filepath.Join(dir, ".gitignore")
+			writeFile(t, path, tt.text)
+
+			s := newState(nil, "valid", append([]string{"-C", dir}, tt.args...)...)
+			require.NoError(t, s.ParseArguments())
+
+			cmd, err := s.Command()
+			require.NoError(t, err)
+			assert.Equal(t, tt.exitcode, cmd.Run())
+			require.NoError(t, s.Logger().ShutdownLoggers())
+			assert.Contains(t, s.Stderr.(*bytes.Buffer).String(), tt.stderr)
+
+			buf, err := ioutil.ReadFile(path)
+			require.NoError(t, err)
+			assert.Equal(t, tt.output, string(buf))
+		})
+	}
+}
@@ -0,0 +1,217 @@
+package state
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+const (
+	// BlankLine is an empty or whitespace only line.
+	BlankLine LineKind = iota
+	// CommentLine is a line starting with an unescaped #.
+	CommentLine
+	// PatternLine is a line containing a pattern.
+	PatternLine
+)
+
+const bom = "\xef\xbb\xbf"
+
+type (
+	// LineKind is the type of a single line in a gitignore file.
+	LineKind uint8
+
+	// Gitignore is a parsed gitignore file.
+	Gitignore struct {
+		Lines []*Line
+		// NoFinalNewline is true if the last line was not terminated by a newline.
+		NoFinalNewline bool
+	}
+
+	// Line is a single line of a gitignore file.
+	Line struct {
+		Kind    LineKind
+		Number  int      // 1-based line number in the source
+		Text    string   // the original text of the line, without the newline
+		Pattern *Pattern // nil unless Kind is PatternLine
+	}
+
+	// Pattern is a parsed gitignore pattern.
+	Pattern struct {
+		// Glob is the pattern with the negation, leading and trailing slashes, and trailing spaces removed. Escape
+		// sequences are preserved so that the glob can be matched as-is.
+		Glob string
+		// Negated is true for patterns starting with !, which re-include matched paths.
+		Negated bool
+		// DirOnly is true for patterns ending with /, which only match directories.
+		DirOnly bool
+		// Anchored is true if the pattern contains a slash other than a trailing slash; the pattern is then matched
+		// relative to the directory of the gitignore file instead of against any path component.
+		Anchored bool
+		// Escaped is true if the pattern contained a backslash escape sequence.
+		Escaped bool
+	}
+)
+
+func (k LineKind) String() string {
+	switch k {
+	case BlankLine:
+		return "blank"
+	case CommentLine:
+		return "comment"
+	case PatternLine:
+		return "pattern"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseGitignore reads gitignore text from the reader and parses it.
+func ParseGitignore(r io.Reader) (*Gitignore, error) {
+	buf, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseGitignoreString(string(buf)), nil
+}
+
+// ParseGitignoreString parses gitignore text. Parsing never fails; anything that isn't blank or a comment is a
+// pattern.
+func ParseGitignoreString(text string) *Gitignore {
+	g := new(Gitignore)
+	if text == "" {
+		return g
+	}
+
+	lines := strings.Split(text, "\n")
+	if last := len(lines) - 1; lines[last] == "" {
+		lines = lines[:last]
+	} else {
+		g.NoFinalNewline = true
+	}
+
+	g.Lines = make([]*Line, len(lines))
+	for idx, text := range lines {
+		line := &Line{Number: idx + 1, Text: text}
+		if idx == 0 {
+			text = strings.TrimPrefix(text, bom)
+		}
+
+		switch {
+		case strings.HasPrefix(text, "#"):
+			line.Kind = CommentLine
+		default:
+			line.Pattern = ParsePattern(text)
+			if line.Pattern == nil {
+				line.Kind = BlankLine
+			} else {
+				line.Kind = PatternLine
+			}
+		}
+
+		g.Lines[idx] = line
+	}
+
+	return g
+}
+
+// ParsePattern parses a single gitignore pattern. Returns nil if the text does not contain a pattern.
+func ParsePattern(text string) *Pattern {
+	text = trimTrailingSpaces(text)
+	if text == "" || strings.HasPrefix(text, "#") {
+		return nil
+	}
+
+	p := new(Pattern)
+	if strings.HasPrefix(text, "!") {
+		p.Negated = true
+		text = text[1:]
+	}
+
+	if strings.HasSuffix(text, "/") {
+		p.DirOnly = true
+		text = text[:len(text)-1]
+	}
+
+	if strings.Contains(text, "/") {
+		p.Anchored = true
+		text = strings.TrimPrefix(text, "/")
+	}
+
+	p.Glob = text
+	p.Escaped = strings.Contains(text, "\\")
+	return p
+}
+
+// trimTrailingSpaces removes trailing spaces not escaped by a backslash, matching git.
+func trimTrailingSpaces(text string) string {
+	end := len(text)
+	for end > 0 && text[end-1] == ' ' {
+		// count the backslashes preceding the space; an odd number escapes it
+		n := 0
+		for i := end - 2; i >= 0 && text[i] == '\\'; i-- {
+			n++
+		}
+		if n%2 == 1 {
+			break
+		}
+		end--
+	}
+
+	return text[:end]
+}
+
+// String renders the pattern in its canonical form.
+func (p *Pattern) String() string {
+	var b strings.Builder
+	if p.Negated {
+		b.WriteByte('!')
+	}
+	if p.Anchored && !strings.Contains(p.Glob, "/") {
+		b.WriteByte('/')
+	}
+	b.WriteString(p.Glob)
+	if p.DirOnly {
+		b.WriteByte('/')
+	}
+	return b.String()
+}
+
+// Patterns returns the lines that contain patterns, in order.
+func (g *Gitignore) Patterns() []*Line {
+	var rv []*Line
+	for _, line := range g.Lines {
+		if line.Kind == PatternLine {
+			rv = append(rv, line)
+		}
+	}
+	return rv
+}
+
+// WriteTo writes the original text of each line. The output is byte-identical to the input of
+// ParseGitignoreString unless the lines have been modified.
+func (g *Gitignore) WriteTo(w io.Writer) (int64, error) {
+	var total int64
+	for idx, line := range g.Lines {
+		text := line.Text
+		if idx < len(g.Lines)-1 || !g.NoFinalNewline {
+			text += "\n"
+		}
+
+		n, err := io.WriteString(w, text)
+		total += int64(n)
+		if err != nil {
+			return total, err
+		}
+	}
+
+	return total, nil
+}
+
+func (g *Gitignore) String() string {
+	var buf bytes.Buffer
+	_, _ = g.WriteTo(&buf)
+	return buf.String()
+}
@@ -0,0 +1,100 @@
+package state
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePattern(t *testing.T) {
+	cases := []struct {
+		text     string
+		expected *Pattern
+		str      string
+	}{
+		{"", nil, ""},
+		{"   ", nil, ""},
+		{"# comment", nil, ""},
+		{"*.log", &Pattern{Glob: "*.log"}, "*.log"},
+		{"*.log   ", &Pattern{Glob: "*.log"}, "*.log"},
+		{"trailing\\ ", &Pattern{Glob: "trailing\\ ", Escaped: true}, "trailing\\ "},
+		{"trailing\\\\ ", &Pattern{Glob: "trailing\\\\", Escaped: true}, "trailing\\\\"},
+		{"!keep.log", &Pattern{Glob: "keep.log", Negated: true}, "!keep.log"},
+		{"\\!bang", &Pattern{Glob: "\\!bang", Escaped: true}, "\\!bang"},
+		{"\\#hash", &Pattern{Glob: "\\#hash", Escaped: true}, "\\#hash"},
+		{"build/", &Pattern{Glob: "build", DirOnly: true}, "build/"},
+		{"/build", &Pattern{Glob: "build", Anchored: true}, "/build"},
+		{"/build/", &Pattern{Glob: "build", DirOnly: true, Anchored: true}, "/build/"},
+		{"doc/*.txt", &Pattern{Glob: "doc/*.txt", Anchored: true}, "doc/*.txt"},
+		{"/doc/*.txt", &Pattern{Glob: "doc/*.txt", Anchored: true}, "doc/*.txt"},
+		{"!/vendor/**/", &Pattern{Glob: "vendor/**", Negated: true, DirOnly: true, Anchored: true}, "!vendor/**/"},
+		{"\tspace", &Pattern{Glob: "\tspace"}, "\tspace"},
+	}
+
+	t.Parallel()
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.text, func(t *testing.T) {
+			p := ParsePattern(tt.text)
+			assert.Equal(t, tt.expected, p)
+			if p != nil {
+				assert.Equal(t, tt.str, p.String())
+			}
+		})
+	}
+}
+
+func TestParseGitignore(t *testing.T) {
+	text := chain(
+		bom+"# Binaries\n",
+		"*.exe\n",
+		"\n",
+		"   \n",
+		"!important.exe\n",
+		"\\#notacomment\n",
+		"vendor/",
+	)
+
+	g, err := ParseGitignore(strings.NewReader(text))
+	require.NoError(t, err)
+	require.Len(t, g.Lines, 7)
+	assert.True(t, g.NoFinalNewline)
+
+	kinds := make([]LineKind, len(g.Lines))
+	for idx, line := range g.Lines {
+		assert.Equal(t, idx+1, line.Number)
+		kinds[idx] = line.Kind
+	}
+	assert.Equal(t, []LineKind{CommentLine, PatternLine, BlankLine, BlankLine, PatternLine, PatternLine, PatternLine}, kinds)
+
+	patterns := g.Patterns()
+	require.Len(t, patterns, 4)
+	assert.Equal(t, 5, patterns[1].Number)
+	assert.True(t, patterns[1].Pattern.Negated)
+	assert.Equal(t, "\\#notacomment", patterns[2].Pattern.Glob)
+	assert.True(t, patterns[3].Pattern.DirOnly)
+
+	assert.Equal(t, text, g.String())
+}
+
+func TestGitignore_RoundTrip(t *testing.T) {
+	cases := []string{
+		"",
+		"\n",
+		"\n\n",
+		"*.o\n",
+		"*.o",
+		"# comment\r\n*.o\r\n",
+		"foo  \n  bar\n\\ \n",
+	}
+
+	t.Parallel()
+	for _, text := range cases {
+		text := text
+		t.Run(text, func(t *testing.T) {
+			assert.Equal(t, text, ParseGitignoreString(text).String())
+		})
+	}
+}
@@ -0,0 +1,370 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+type (
+	// DirUpdate is the update of the gitignore file of one project directory in a recursive update. The name of the
+	// update is relative to the working directory.
+	DirUpdate struct {
+		*Update
+		// Dir is the slash separated project directory relative to the working directory, "." for the root.
+		Dir string
+
+		// state runs in the project directory, where its manifest and lockfile are
+		state *State
+	}
+
+	// UpdateSummary describes the change to one gitignore file in a recursive update.
+	UpdateSummary struct {
+		File    string   `json:"file"`
+		Status  string   `json:"status"`
+		Added   []string `json:"added"`
+		Updated []string `json:"updated"`
+		Removed []string `json:"removed"`
+	}
+)
+
+// runRecursive updates the gitignore file of every project in the working tree and prints a summary of the files.
+// Nothing is written if any has conflicting local edits, unless -conflict-markers is set. A file that can't be
+// written doesn't stop the others; it is reported as failed and 1 is returned.
+func (c *updateCommand) runRecursive() ExitStatus {
+	s := (*State)(c)
+	logger := s.Logger()
+
+	if len(s.templates) > 0 {
+		logger.Error("-recursive doesn't take templates; each directory's are read from its manifest or detected")
+		return 1
+	}
+	if s.target != "" && s.target != "gitignore" {
+		logger.Errorf("-recursive only manages .gitignore files, not -target %s", s.target)
+		return 1
+	}
+
+	updates, err := s.PlanRecursive()
+	if err != nil {
+		logger.Error(err.Error())
+		return 1
+	}
+
+	var conflicts int
+	for _, d := range updates {
+		logger.Infof("planning %s", d.Name)
+		s.reportUpdate(d.Update)
+		conflicts += len(d.Conflicts)
+	}
+
+	if conflicts > 0 && !s.conflictMarkers {
+		logger.Errorf("local edits conflict with upstream changes in %d places; resolve them or use -conflict-markers", conflicts)
+		return 1
+	}
+
+	var (
+		failed    int
+		summaries = make([]UpdateSummary, 0, len(updates))
+	)
+	for _, d := range updates {
+		sum := d.Summary()
+		if err := d.write(s); err != nil {
+			logger.Error(err.Error())
+			sum.Status = "failed"
+			failed++
+		}
+		summaries = append(summaries, sum)
+	}
+
+	if err := s.printSummaries(summaries); err != nil {
+		logger.Error(err.Error())
+		return 1
+	}
+
+	if failed > 0 {
+		logger.Errorf("%d of %d files couldn't be written", failed, len(updates))
+		return 1
+	}
+
+	if conflicts > 0 {
+		logger.Errorf("wrote %d conflicts between markers", conflicts)
+		return 1
+	}
+
+	return 0
+}
+
+// write writes the planned gitignore file if it changed and brings the lockfile of the directory up to date.
+func (d *DirUpdate) write(s *State) error {
+	if d.Old.String() != d.New.String() {
+		if err := s.WriteGitignore(d.Name, d.New); err != nil {
+			return err
+		}
+	}
+	return d.state.updateLock(d.Update)
+}
+
+func (s *State) printSummaries(summaries []UpdateSummary) error {
+	if s.format == "json" {
+		enc := json.NewEncoder(s.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(summaries)
+	}
+
+	for _, sum := range summaries {
+		if _, err := fmt.Fprintln(s.Stdout, sum.String()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// PlanRecursive plans the update of the gitignore file of each project in the working tree, parents before their
+// children. A directory is a project if it has a manifest, a gitignore file with managed blocks, or files suggesting
+// templates that the gitignore file of no parent project includes. Since git scopes the patterns of a gitignore file
+// to its directory, a template is only added where it is first needed, unless its rules are anchored to the parent.
+// Templates for editors and operating systems (those under Global/) are only suggested for the root. Directories
+// ignored by the planned files, dependency directories, nested repositories and submodules aren't searched. Every
+// file is planned from the same catalog of each repository.
+func (s *State) PlanRecursive() ([]*DirUpdate, error) {
+	cat, err := s.Catalog()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		root      = s.Path()
+		logger    = s.Logger()
+		catalogs  = map[string]*Catalog{catalogKey(s.repo, DefaultRef): cat}
+		ignore    = new(Matcher)
+		inherited = make(map[string]map[string]bool)
+		rv        []*DirUpdate
+	)
+
+	// skip logs a file or directory that can't be read and leaves it out of the update
+	skip := func(name string, info os.FileInfo, err error) error {
+		if name == root {
+			return err
+		}
+		logger.Warnf("skipping %s: %v", name, err)
+		if info == nil || !info.IsDir() {
+			return nil
+		}
+		return filepath.SkipDir
+	}
+
+	err = filepath.Walk(root, func(name string, info os.FileInfo, walkErr error) error {
+		if walkErr != nil {
+			return skip(name, info, walkErr)
+		}
+		if !info.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, name)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if rel != "." {
+			if skipDir(info.Name(), rel, ignore) {
+				return filepath.SkipDir
+			}
+			// nested repositories and submodules manage their own gitignore files
+			if _, err := os.Lstat(filepath.Join(name, ".git")); err == nil {
+				return filepath.SkipDir
+			}
+		}
+
+		templates := make(map[string]bool)
+		if rel != "." {
+			for t := range inherited[path.Dir(rel)] {
+				templates[t] = true
+			}
+		}
+		inherited[rel] = templates
+
+		d, err := s.planDir(cat, catalogs, rel, templates)
+		if _, ok := err.(*os.PathError); ok {
+			return skip(name, info, err)
+		} else if err != nil {
+			return err
+		}
+
+		base := rel
+		if base == "." {
+			base = ""
+		}
+		if d == nil {
+			g, err := s.ReadGitignore(path.Join(rel, GitignoreFile))
+			if _, ok := err.(*os.PathError); ok {
+				return skip(name, info, err)
+			} else if err != nil {
+				return err
+			}
+			ignore.Add(base, g, path.Join(rel, GitignoreFile))
+			return nil
+		}
+
+		blocks, err := d.New.Blocks()
+		if err != nil {
+			return fmt.Errorf("%s: %v", d.Name, err)
+		}
+		for _, b := range blocks {
+			if !anchored(d.New, b) {
+				templates[b.Name] = true
+			}
+		}
+
+		ignore.Add(base, d.New, d.Name)
+		rv = append(rv, d)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return rv, nil
+}
+
+// planDir plans the update of the gitignore file of the directory, returning nil if the directory isn't a project.
+// Templates already included by a parent project are not suggested again. Manifests take their catalogs from, and
+// add them to, the catalogs fetched so far.
+func (s *State) planDir(cat *Catalog, catalogs map[string]*Catalog, rel string, inherited map[string]bool) (*DirUpdate, error) {
+	child := *s
+	child.dir = s.Path(filepath.FromSlash(rel))
+
+	var (
+		u   *Update
+		err error
+	)
+
+	m, err := child.ReadManifest()
+	if err != nil {
+		return nil, err
+	}
+
+	if m != nil {
+		u, err = child.planManifest(m, m.Output, catalogs)
+	} else {
+		var templates []string
+		if templates, err = child.dirTemplates(cat, rel == ".", inherited); err != nil || len(templates) == 0 {
+			return nil, err
+		}
+		u, err = child.planTemplates(GitignoreFile, cat, templates)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	u.Name = path.Join(rel, u.Name)
+	return &DirUpdate{Update: u, Dir: rel, state: &child}, nil
+}
+
+// dirTemplates returns the templates of the managed blocks in the gitignore file of the working directory, followed
+// by the templates suggested by the files directly in it that aren't inherited.
+func (s *State) dirTemplates(cat *Catalog, root bool, inherited map[string]bool) ([]string, error) {
+	g, err := s.ReadGitignore(GitignoreFile)
+	if err != nil {
+		return nil, err
+	}
+
+	blocks, err := g.Blocks()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", s.Path(GitignoreFile), err)
+	}
+
+	var templates []string
+	for _, b := range blocks {
+		if b.Path != "" && (b.Repo == "" || b.Repo == cat.Repo) {
+			templates = append(templates, b.Path)
+		}
+	}
+
+	entries, err := ioutil.ReadDir(s.Path())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, rule := range DefaultDetectRules {
+		t := cat.Find(rule.Template)
+		if t == nil || inherited[t.Name] || (!root && strings.HasPrefix(t.Path, "Global/")) {
+			continue
+		}
+		for _, e := range entries {
+			if rule.matches(e.Name(), e.IsDir()) {
+				templates = append(templates, t.Path)
+				break
+			}
+		}
+	}
+
+	return templates, nil
+}
+
+// anchored reports whether any rule of the block only matches relative to the directory of the gitignore file, as
+// build/Release does, so that the template is still needed in subdirectories. Rules starting with **/ match at any
+// depth.
+func anchored(g *Gitignore, b *Block) bool {
+	for _, line := range b.Contents(g) {
+		if line.Kind == PatternLine && line.Pattern.Anchored && !strings.HasPrefix(line.Pattern.Glob, "**/") {
+			return true
+		}
+	}
+	return false
+}
+
+// Summary describes the change to the gitignore file. With -dry-run the status says what would change.
+func (d *DirUpdate) Summary() UpdateSummary {
+	sum := UpdateSummary{
+		File:    d.Name,
+		Status:  "unchanged",
+		Added:   d.Added,
+		Updated: d.Updated,
+		Removed: d.Removed,
+	}
+
+	switch {
+	case len(d.Conflicts) > 0:
+		sum.Status = "conflicts"
+	case d.Old.String() == d.New.String():
+	case len(d.Old.Lines) == 0:
+		sum.Status = "created"
+	default:
+		sum.Status = "updated"
+	}
+	if d.state != nil && d.state.dryRun && (sum.Status == "created" || sum.Status == "updated") {
+		sum.Status = "would be " + sum.Status
+	}
+
+	for _, names := range []*[]string{&sum.Added, &sum.Updated, &sum.Removed} {
+		if *names == nil {
+			*names = []string{}
+		}
+	}
+
+	return sum
+}
+
+func (sum UpdateSummary) String() string {
+	var changes []string
+	for _, c := range []struct {
+		verb  string
+		names []string
+	}{{"added", sum.Added}, {"updated", sum.Updated}, {"removed", sum.Removed}} {
+		if len(c.names) > 0 {
+			changes = append(changes, c.verb+" "+strings.Join(c.names, ", "))
+		}
+	}
+
+	if len(changes) == 0 {
+		return fmt.Sprintf("%s\t%s", sum.File, sum.Status)
+	}
+	return fmt.Sprintf("%s\t%s (%s)", sum.File, sum.Status, strings.Join(changes, "; "))
+}
@@ -0,0 +1,218 @@
+package state
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateCommand_Recursive(t *testing.T) {
+	t.Parallel()
+
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	files := map[string]string{
+		".gitignore":                     "/vendor/\n",
+		".DS_Store":                      "",
+		"svc/api/go.mod":                 "module api\n",
+		"svc/api/internal/db/db.go":      "package db\n",
+		"svc/web/.DS_Store":              "",
+		"tools/lint/.gitignore.toml":     "templates = [\"Go\"]\nlocal = [\"/report.txt\"]\n",
+		"vendor/example.com/mod/go.mod":  "module mod\n",
+		"docs/guide/index.md":            "# guide\n",
+		"svc/api/internal/db/.gitignore": "*.sqlite\n",
+		"libs/sub/.git":                  "gitdir: ../../.git/modules/sub\n",
+		"libs/sub/go.mod":                "module sub\n",
+		"web/node_modules/mod/go.mod":    "module mod\n",
+	}
+	for name, content := range files {
+		writeFile(t, filepath.Join(dir, filepath.FromSlash(name)), content)
+	}
+
+	run := func(args ...string) (ExitStatus, string, string) {
+		s := newState(nil, "valid", append([]string{"-C", dir}, args...)...)
+		require.NoError(t, s.ParseArguments())
+
+		cmd, err := s.Command()
+		require.NoError(t, err)
+		rv := cmd.Run()
+		require.NoError(t, s.Logger().ShutdownLoggers())
+		return rv, s.Stdout.(*bytes.Buffer).String(), s.Stderr.(*bytes.Buffer).String()
+	}
+
+	rv, stdout, stderr := run("update", "-recursive", "-dry-run")
+	assert.Equal(t, ExitStatus(0), rv, stderr)
+	assert.Contains(t, stdout, "+++ b/svc/api/.gitignore\n")
+	assert.True(t, strings.HasSuffix(stdout, chain(
+		".gitignore\twould be updated (added macOS)\n",
+		"svc/api/.gitignore\twould be created (added Go)\n",
+		"tools/lint/.gitignore\twould be created (added Go, local)\n",
+	)), stdout)
+	_, err := os.Stat(filepath.Join(dir, "svc", "api", ".gitignore"))
+	assert.True(t, os.IsNotExist(err))
+
+	rv, stdout, stderr = run("update", "-recursive")
+	assert.Equal(t, ExitStatus(0), rv, stderr)
+	assert.Equal(t, chain(
+		".gitignore\tupdated (added macOS)\n",
+		"svc/api/.gitignore\tcreated (added Go)\n",
+		"tools/lint/.gitignore\tcreated (added Go, local)\n",
+	), stdout)
+
+	read := func(name string) string {
+		buf, err := ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		require.NoError(t, err)
+		return string(buf)
+	}
+
+	assert.True(t, strings.HasPrefix(read(".gitignore"), "/vendor/\n\n# BEGIN update-gitignore: macOS "))
+	assert.True(t, strings.HasPrefix(read("svc/api/.gitignore"), "# BEGIN update-gitignore: Go repo=github/gitignore path=Go.gitignore sha=f2dd9554a12fd7acdc62e60e8eccae086f718be2\n"))
+	assert.Contains(t, read("tools/lint/.gitignore"), "# BEGIN update-gitignore: local\n/report.txt\n# END update-gitignore: local\n")
+	assert.Equal(t, "*.sqlite\n", read("svc/api/internal/db/.gitignore"))
+
+	// Go is inherited from svc/api, macOS is only added to the root, vendor is ignored, libs/sub is a submodule and
+	// node_modules holds dependencies
+	for _, name := range []string{
+		"svc/web/.gitignore",
+		"vendor/example.com/mod/.gitignore",
+		"docs/guide/.gitignore",
+		"libs/sub/.gitignore",
+		"web/node_modules/mod/.gitignore",
+	} {
+		_, err := os.Stat(filepath.Join(dir, filepath.FromSlash(name)))
+		assert.True(t, os.IsNotExist(err), name)
+	}
+
+	rv, stdout, _ = run("-format", "json", "update", "-recursive")
+	assert.Equal(t, ExitStatus(0), rv)
+	assert.Equal(t, `[
+  {
+    "file": ".gitignore",
+    "status": "unchanged",
+    "added": [],
+    "updated": [],
+    "removed": []
+  },
+  {
+    "file": "svc/api/.gitignore",
+    "status": "unchanged",
+    "added": [],
+    "updated": [],
+    "removed": []
+  },
+  {
+    "file": "tools/lint/.gitignore",
+    "status": "unchanged",
+    "added": [],
+    "updated": [],
+    "removed": []
+  }
+]
+`, stdout)
+
+	rv, _, stderr = run("update", "-recursive", "Go")
+	assert.Equal(t, ExitStatus(1), rv)
+	assert.Contains(t, stderr, "-recursive doesn't take templates")
+}
+
+func TestUpdateCommand_Recursive_WriteError(t *testing.T) {
+	t.Parallel()
+
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	// the lockfile of a can't be read, so its update fails after .gitignore is written
+	writeFile(t, filepath.Join(dir, "a", "go.mod"), "module a\n")
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "a", LockFile), 0755))
+	writeFile(t, filepath.Join(dir, "b", "go.mod"), "module b\n")
+	writeFile(t, filepath.Join(dir, "c", ".gitignore.toml"), "templates = [\"Go\"]\n")
+
+	s := newState(nil, "valid", "-C", dir, "update", "-recursive")
+	require.NoError(t, s.ParseArguments())
+
+	var branches int
+	replay := s.httpClient.Transport
+	s.httpClient.Transport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if strings.Contains(req.URL.Path, "/branches/") {
+			branches++
+		}
+		return replay.RoundTrip(req)
+	})
+
+	cmd, err := s.Command()
+	require.NoError(t, err)
+	assert.Equal(t, ExitStatus(1), cmd.Run())
+	require.NoError(t, s.Logger().ShutdownLoggers())
+	assert.Equal(t, chain(
+		"a/.gitignore\tfailed (added Go)\n",
+		"b/.gitignore\tcreated (added Go)\n",
+		"c/.gitignore\tcreated (added Go)\n",
+	), s.Stdout.(*bytes.Buffer).String())
+	assert.Contains(t, s.Stderr.(*bytes.Buffer).String(), "1 of 3 files couldn't be written")
+
+	// every directory is planned from the one catalog
+	assert.Equal(t, 1, branches)
+}
+
+func TestUpdateCommand_Recursive_Unreadable(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("permissions aren't enforced for root")
+	}
+	t.Parallel()
+
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	writeFile(t, filepath.Join(dir, "go.mod"), "module root\n")
+	writeFile(t, filepath.Join(dir, "locked", "go.mod"), "module locked\n")
+	require.NoError(t, os.Chmod(filepath.Join(dir, "locked"), 0))
+	defer os.Chmod(filepath.Join(dir, "locked"), 0755)
+
+	s := newState(nil, "valid", "-C", dir, "update", "-recursive")
+	require.NoError(t, s.ParseArguments())
+
+	cmd, err := s.Command()
+	require.NoError(t, err)
+	assert.Equal(t, ExitStatus(0), cmd.Run())
+	require.NoError(t, s.Logger().ShutdownLoggers())
+	assert.Equal(t, ".gitignore\tcreated (added Go)\n", s.Stdout.(*bytes.Buffer).String())
+	assert.Contains(t, s.Stderr.(*bytes.Buffer).String(), "skipping "+filepath.Join(dir, "locked"))
+}
+
+func TestAnchored(t *testing.T) {
+	cases := []struct {
+		name     string
+		content  string
+		expected bool
+	}{
+		{"unanchored", "*.log\nnode_modules/\n", false},
+		{"anchored", "node_modules/\nbuild/Release\n", true},
+		{"leading slash", "/coverage\n", true},
+		{"any depth", "**/logs\n**/build/Release\n", false},
+		{"commented", "# build/Release\n", false},
+	}
+
+	t.Parallel()
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			g := ParseGitignoreString(chain(
+				"# BEGIN update-gitignore: Node\n",
+				tt.content,
+				"# END update-gitignore: Node\n",
+			))
+			blocks, err := g.Blocks()
+			require.NoError(t, err)
+			require.Len(t, blocks, 1)
+			assert.Equal(t, tt.expected, anchored(g, blocks[0]))
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type (
+	showCommand State
+
+	// TemplateDetails describes a template, the last commit that changed it and its content.
+	TemplateDetails struct {
+		Name       string         `json:"name"`
+		Path       string         `json:"path"`
+		Size       uint64         `json:"size"`
+		SHA        string         `json:"sha"`
+		URL        string         `json:"url"`
+		LastCommit *CommitSummary `json:"last_commit"`
+		Content    string         `json:"content"`
+	}
+
+	// CommitSummary is the part of a commit shown to users.
+	CommitSummary struct {
+		SHA     string    `json:"sha"`
+		Author  string    `json:"author"`
+		Email   string    `json:"email"`
+		Date    time.Time `json:"date"`
+		Message string    `json:"message"`
+	}
+)
+
+func (c *showCommand) GetName() string { return "show" }
+
+// Run prints the metadata of the named template followed by its content with line numbers.
+func (c *showCommand) Run() ExitStatus {
+	s := (*State)(c)
+	logger := s.Logger()
+
+	if len(s.templates) != 1 {
+		logger.Error("show requires exactly one template")
+		return 2
+	}
+
+	details, err := s.Show(s.templates[0])
+	if err != nil {
+		logger.Error(err.Error())
+		return 1
+	}
+
+	if s.format == "json" {
+		enc := json.NewEncoder(s.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(details)
+	} else {
+		err = details.write(s)
+	}
+
+	if err != nil {
+		logger.Error(err.Error())
+		return 1
+	}
+
+	return 0
+}
+
+// Show looks up the named template, the last commit that changed it and its content.
+func (s *State) Show(name string) (*TemplateDetails, error) {
+	cat, err := s.Catalog()
+	if err != nil {
+		return nil, err
+	}
+
+	templates, err := cat.Resolve(name)
+	if err != nil {
+		return nil, err
+	}
+	t := templates[0]
+
+	cl, err := s.clientFor(cat.Repo)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := cl.GetTemplate(t)
+	if err != nil {
+		return nil, err
+	}
+
+	details := &TemplateDetails{
+		Name:    t.Name,
+		Path:    t.Path,
+		Size:    t.Size,
+		SHA:     t.SHA,
+		URL:     cl.BlobURL(cat.Ref, t.Path),
+		Content: content,
+	}
+
+	commit, err := cl.GetLastCommit(cat.Commit, t.Path)
+	if err != nil {
+		return nil, err
+	}
+	if commit != nil {
+		details.LastCommit = newCommitSummary(commit)
+	}
+
+	return details, nil
+}
+
+// write prints the details as text.
+func (d *TemplateDetails) write(s *State) error {
+	fields := [][2]string{
+		{"Name", d.Name},
+		{"Path", d.Path},
+		{"Size", fmt.Sprintf("%d bytes", d.Size)},
+		{"SHA", d.SHA},
+	}
+	if c := d.LastCommit; c != nil {
+		subject := strings.SplitN(c.Message, "\n", 2)[0]
+		fields = append(fields,
+			[2]string{"Commit", c.SHA + " " + subject},
+			[2]string{"Author", fmt.Sprintf("%s <%s>", c.Author, c.Email)},
+			[2]string{"Date", c.Date.Format(time.RFC3339)},
+		)
+	}
+	fields = append(fields, [2]string{"URL", d.URL})
+
+	for _, f := range fields {
+		if _, err := fmt.Fprintf(s.Stdout, "%-8s %s\n", f[0]+":", f[1]); err != nil {
+			return err
+		}
+	}
+
+	lines := strings.Split(strings.TrimSuffix(d.Content, "\n"), "\n")
+	width := len(strconv.Itoa(len(lines)))
+	if _, err := fmt.Fprintln(s.Stdout); err != nil {
+		return err
+	}
+	for idx, line := range lines {
+		// the line is printed verbatim since trailing spaces can be significant, e.g. in `foo\ `
+		numbered := fmt.Sprintf("%*d", width, idx+1)
+		if line != "" {
+			numbered += "  " + line
+		}
+		if _, err := fmt.Fprintln(s.Stdout, numbered); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,111 @@
+package state
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShowCommand_Run(t *testing.T) {
+	cases := []struct {
+		name     string
+		args     []string
+		stdout   string
+		exitcode ExitStatus
+	}{
+		{
+			"text",
+			[]string{"show", "Global/Linux"},
+			chain(
+				"Name:    Linux\n",
+				"Path:    Global/Linux.gitignore\n",
+				"Size:    316 bytes\n",
+				"SHA:     b56bf65d85583b03eeccfaa2a927084583a33e91\n",
+				"Commit:  56e3f5a7b2a67413a1d3e33fceb8100898015a2e [Unity] Added leading slashes to ignored directories ",
+				"so that valid subdirectories aren't ignored incorrectly (#2980)\n",
+				"Author:  Lucas Steer <LucasSteer@users.noreply.github.com>\n",
+				"Date:    2019-03-23T18:29:17Z\n",
+				"URL:     https://github.com/github/gitignore/blob/master/Global/Linux.gitignore\n",
+				"\n",
+				" 1  *~\n",
+				" 2\n",
+				" 3  # temporary files which can be created if a process still has a handle open of a deleted file\n",
+				" 4  .fuse_hidden*\n",
+				" 5\n",
+				" 6  # KDE directory preferences\n",
+				" 7  .directory\n",
+				" 8\n",
+				" 9  # Linux trash folder which might appear on any partition or disk\n",
+				"10  .Trash-*\n",
+				"11\n",
+				"12  # .nfs files are created when an open file is removed but is still being accessed\n",
+				"13  .nfs*\n",
+			),
+			0,
+		},
+		{"no template", []string{"show"}, "", 2},
+		{"many templates", []string{"show", "Go", "Global/Linux"}, "", 2},
+		{"unknown template", []string{"show", "Nonexistent"}, "", 1},
+	}
+
+	t.Parallel()
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			s := newState(nil, "valid", tt.args...)
+			defer s.Logger().ShutdownLoggers()
+			require.NoError(t, s.ParseArguments())
+
+			cmd, err := s.Command()
+			require.NoError(t, err)
+			assert.Equal(t, "show", cmd.GetName())
+			assert.Equal(t, tt.exitcode, cmd.Run())
+			assert.Equal(t, tt.stdout, s.Stdout.(*bytes.Buffer).String())
+		})
+	}
+}
+
+func TestState_Show(t *testing.T) {
+	s := newState(nil, "valid", "-format", "json", "show", "Go")
+	defer s.Logger().ShutdownLoggers()
+	require.NoError(t, s.ParseArguments())
+
+	cmd, err := s.Command()
+	require.NoError(t, err)
+	require.Equal(t, ExitStatus(0), cmd.Run())
+
+	var details TemplateDetails
+	require.NoError(t, json.Unmarshal(s.Stdout.(*bytes.Buffer).Bytes(), &details))
+	assert.Equal(t, "Go", details.Name)
+	assert.Equal(t, "Go.gitignore", details.Path)
+	assert.Equal(t, "f2dd9554a12fd7acdc62e60e8eccae086f718be2", details.SHA)
+	assert.Equal(t, "https://github.com/github/gitignore/blob/master/Go.gitignore", details.URL)
+	assert.Contains(t, details.Content, "*.exe\n")
+	require.NotNil(t, details.LastCommit)
+	assert.Equal(t, "56e3f5a7b2a67413a1d3e33fceb8100898015a2e", details.LastCommit.SHA)
+	assert.Equal(t, "Lucas Steer", details.LastCommit.Author)
+	assert.Equal(t, "2019-03-23T18:29:17Z", details.LastCommit.Date.Format(time.RFC3339))
+}
+
+func TestTemplateDetails_write(t *testing.T) {
+	s := newState(nil, "valid", "show")
+	defer s.Logger().ShutdownLoggers()
+
+	d := &TemplateDetails{Name: "Spaces", Path: "Spaces.gitignore", Size: 19, SHA: "abc", URL: "https://example.com", Content: "foo\\ \n\nbar  \n"}
+	require.NoError(t, d.write(s))
+	assert.Equal(t, chain(
+		"Name:    Spaces\n",
+		"Path:    Spaces.gitignore\n",
+		"Size:    19 bytes\n",
+		"SHA:     abc\n",
+		"URL:     https://example.com\n",
+		"\n",
+		"1  foo\\ \n",
+		"2\n",
+		"3  bar  \n",
+	), s.Stdout.(*bytes.Buffer).String())
+}
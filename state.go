@@ -5,7 +5,10 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"net/http"
+	"path/filepath"
 	"strings"
+	"text/template"
 	"time"
 
 	"github.com/aphistic/gomol"
@@ -18,48 +21,128 @@ var (
 	ErrActionRequired = errors.New("need an action")
 	// ErrInvalidRepo is returned if the repo provided on the command line does not look like <owner>/<name>.
 	ErrInvalidRepo = errors.New("invalid repo")
+	// ErrInvalidFormat is returned if the output format provided on the command line is not recognized.
+	ErrInvalidFormat = errors.New("invalid format")
 )
 
+// Formats are the output formats accepted by -format. List also accepts a Go template, e.g. '{{.Name}}\t{{.Size}}'.
+var Formats = []string{"text", "json", "ndjson", "table"}
+
 // The State of the application.
 type State struct {
 	*app.App
 
 	// command-line flags
-	debug     bool
-	repo      string
-	timeout   time.Duration
-	action    string
-	templates []string
+	debug           bool
+	dedupe          bool
+	names           bool
+	force           bool
+	yes             bool
+	after           string
+	conflictMarkers bool
+	recursive       bool
+	dryRun          bool
+	backup          bool
+	target          string
+	repo            string
+	timeout         time.Duration
+	format          string
+	dir             string
+	action          string
+	templates       []string
+
+	// flags are the global flags and actionFlags the flags of each action
+	flags       *flag.FlagSet
+	actionFlags map[string]*flag.FlagSet
+
+	// settings are the effective flag values and their origins
+	settings []Setting
+
+	// formatTemplate is the parsed -format if it is a Go template
+	formatTemplate *template.Template
+
+	// httpClient overrides the client used for GitHub requests, used for testing
+	httpClient *http.Client
 }
 
 func (s *State) ParseArguments() error {
 	fs := flag.NewFlagSet("update-gitignore", flag.ContinueOnError)
 	fs.SetOutput(s.Stderr)
 	fs.Usage = usage(fs)
+	s.flags = fs
 
 	debug := fs.Bool("debug", false, "print debug statements to STDERR")
 	repo := fs.String("repo", "github/gitignore", "the template repository to use")
 	timeout := fs.Duration("timeout", time.Second*30, "the max duration for network requests (0 for no timeout)")
+	format := fs.String("format", "text", "the output format (text, json, ndjson, table or a Go template for list)")
+	dir := fs.String("C", ".", "run as if started in this directory")
 
 	if err := fs.Parse(s.Arguments); err != nil {
 		return err
 	}
 
+	args := fs.Args()
+	if len(args) > 0 {
+		s.action = args[0]
+		s.templates = args[1:]
+	}
+
+	// every action's flags are declared so configuration can set them
+	s.actionFlags = make(map[string]*flag.FlagSet, len(Commands))
+	for _, spec := range Commands {
+		s.actionFlags[spec.Name] = s.newActionFlags(spec)
+	}
+
+	if len(args) > 0 {
+		if afs := s.actionFlags[s.action]; afs != nil {
+			if err := afs.Parse(args[1:]); err != nil {
+				return err
+			}
+			s.templates = afs.Args()
+		}
+	}
+
+	// the project configuration is read from the working directory
+	s.SetDir(*dir)
+	if err := s.applyConfig(fs, s.actionFlags); err != nil {
+		return err
+	}
+
 	s.SetDebug(*debug)
 	s.SetRepo(*repo)
 	s.SetTimeout(*timeout)
+	if err := s.SetFormat(*format); err != nil {
+		return err
+	}
 
-	args := fs.Args()
 	if len(args) == 0 {
 		fs.Usage()
 		return ErrActionRequired
 	}
 
-	s.action = args[0]
-	s.templates = args[1:]
 	return nil
 }
 
+// newActionFlags declares the flags of the action. Flags after the action that it doesn't declare are reported
+// with the usage of the action. Only the flags of the action being run are bound to the state, so actions can
+// share flag names without configuring each other.
+func (s *State) newActionFlags(spec *CommandSpec) *flag.FlagSet {
+	afs := flag.NewFlagSet("update-gitignore "+spec.Name, flag.ContinueOnError)
+	afs.SetOutput(s.Stderr)
+	afs.Usage = func() { spec.Usage(afs.Output(), afs) }
+
+	target := s
+	if spec.Name != s.action {
+		target = new(State)
+	}
+
+	if cmd, ok := spec.New(target).(FlagCommand); ok {
+		cmd.Flags(afs)
+	}
+
+	return afs
+}
+
 func (s *State) SetDebug(debug bool) {
 	logger := s.Logger()
 	s.debug = debug
@@ -95,29 +178,88 @@ func (s *State) Timeout() time.Duration {
 	return s.timeout
 }
 
+func (s *State) Dedupe() bool {
+	return s.dedupe
+}
+
+func (s *State) Names() bool {
+	return s.names
+}
+
+func (s *State) Force() bool {
+	return s.force
+}
+
+func (s *State) Yes() bool {
+	return s.yes
+}
+
+func (s *State) After() string {
+	return s.after
+}
+
+func (s *State) SetDir(dir string) {
+	if dir == "" {
+		dir = "."
+	}
+
+	s.dir = dir
+}
+
+func (s *State) Dir() string {
+	return s.dir
+}
+
+func (s *State) SetFormat(format string) error {
+	s.formatTemplate = nil
+	if strings.Contains(format, "{{") {
+		// shells don't expand escapes in quoted arguments
+		text := strings.NewReplacer(`\t`, "\t", `\n`, "\n").Replace(format)
+		tmpl, err := template.New("format").Parse(text)
+		if err != nil {
+			return fmt.Errorf("%v: %v", ErrInvalidFormat, err)
+		}
+		s.formatTemplate = tmpl
+	} else if !contains(Formats, format) {
+		return ErrInvalidFormat
+	}
+
+	s.format = format
+	return nil
+}
+
+func (s *State) Format() string {
+	return s.format
+}
+
 func (s *State) Command() (Command, error) {
-	switch s.action {
-	case "dump":
-		return (*dumpCommand)(s), nil
-	case "list":
-		return (*listCommand)(s), nil
-	default:
+	spec := LookupCommand(s.action)
+	if spec == nil {
 		return nil, fmt.Errorf("unrecognized action %s", s.action)
 	}
+
+	return spec.New(s), nil
 }
 
 func (s *State) Client() (*Client, error) {
-	slice := strings.SplitN(s.repo, "/", 2)
+	return s.clientFor(s.repo)
+}
+
+// clientFor returns a client for the template repository, given as <owner>/<name>.
+func (s *State) clientFor(repo string) (*Client, error) {
+	slice := strings.SplitN(repo, "/", 2)
 	if len(slice) != 2 {
 		return nil, ErrInvalidRepo
 	}
 
 	cl := &Client{
-		state: s,
-		owner: slice[0],
-		repo:  slice[1],
+		state:     s,
+		owner:     slice[0],
+		repo:      slice[1],
+		host:      DefaultHost,
+		providers: DefaultTokenProviders(),
 	}
-	cl.SetHTTPClient(nil)
+	cl.SetHTTPClient(s.httpClient)
 
 	return cl, nil
 }
@@ -130,19 +272,53 @@ func (s *State) deadline() (context.Context, context.CancelFunc) {
 	return s.Context, func() {}
 }
 
+func (s *State) homeDir() string {
+	home, _ := s.LookupEnv("HOME")
+	return home
+}
+
+func (s *State) configDir() string {
+	if dir, _ := s.LookupEnv("XDG_CONFIG_HOME"); dir != "" {
+		return dir
+	}
+
+	if home := s.homeDir(); home != "" {
+		return filepath.Join(home, ".config")
+	}
+
+	return ""
+}
+
 func usage(flagset *flag.FlagSet) func() {
 	return func() {
-		fmt.Fprintln(flagset.Output(), `usage: update-gitignore [{flags}] {action} [{template}...]
-Actions:
-  dump - dumps the selected template(s) to STDOUT
-  list - lists the available templates, optionally filtered by the provided arguments
-
-{flags}    - Command line flags (see below)
-{template} - The Template to dump (required for "dump") or a search string to filter (optional for "list")
+		w := flagset.Output()
+		fmt.Fprintln(w, "usage: update-gitignore [{flags}] {action} [{action flags}] [{args}...]")
+		fmt.Fprintln(w, "Actions:")
+		for _, spec := range Commands {
+			if !spec.Hidden {
+				fmt.Fprintf(w, "  %s - %s\n", spec.Name, spec.Summary)
+			}
+		}
+		fmt.Fprintln(w, `
+{flags}        - Global flags (see below)
+{action flags} - Flags of the action, see "update-gitignore help {action}"
+{args}         - The arguments of the action, usually template names
 
 Examples:
   update-gitignore list go
+  update-gitignore show Global/macOS
   update-gitignore -debug dump Go > .gitignore
+  update-gitignore dump -dedupe Go Node VisualStudioCode > .gitignore
+  update-gitignore -format json auth status
+  update-gitignore check-ignore build/app.log
+  update-gitignore diff Node
+  update-gitignore changes Go
+  update-gitignore update $(update-gitignore detect -names)
+  update-gitignore update -recursive
+  update-gitignore init -yes JetBrains
+  update-gitignore add -after Go Node
+  UPDATE_GITIGNORE_TIMEOUT=1m update-gitignore config show
+  update-gitignore help dump
 
 Flags:`)
 		flagset.PrintDefaults()
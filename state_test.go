@@ -15,21 +15,49 @@ import (
 
 var (
 	usageValue = chain(
-		"usage: update-gitignore [{flags}] {action} [{template}...]\n",
+		"usage: update-gitignore [{flags}] {action} [{action flags}] [{args}...]\n",
 		"Actions:\n",
 		"  dump - dumps the selected template(s) to STDOUT\n",
 		"  list - lists the available templates, optionally filtered by the provided arguments\n",
+		"  show - prints a template's metadata, the last commit that changed it and its numbered content\n",
+		"  auth - reports the authenticated user, token source, scopes and rate limits\n",
+		"  check-ignore - explains which rule and template block in .gitignore ignores each path\n",
+		"  lint - checks .gitignore, or the selected templates, for conflicting and redundant rules\n",
+		"  update - refreshes the managed blocks in .gitignore, adding blocks for any named templates\n",
+		"  diff - shows the changes update would make to .gitignore and the tracked files it would ignore\n",
+		"  changes - lists the upstream commits and changes to the templates of managed blocks since they were fetched\n",
+		"  detect - suggests templates based on marker files in the working tree\n",
+		"  init - creates .gitignore and its lockfile from the detected and selected templates\n",
+		"  add - adds managed blocks for the selected templates to .gitignore\n",
+		"  remove - removes the managed blocks for the selected templates from .gitignore\n",
+		"  config - shows the effective settings and where each was set\n",
+		"  completion - prints the shell completion script, e.g. source <(update-gitignore completion bash)\n",
+		"  help - shows the usage of an action\n",
 		"\n",
-		"{flags}    - Command line flags (see below)\n",
-		"{template} - The Template to dump (required for \"dump\") ",
-		"or a search string to filter (optional for \"list\")\n",
+		"{flags}        - Global flags (see below)\n",
+		"{action flags} - Flags of the action, see \"update-gitignore help {action}\"\n",
+		"{args}         - The arguments of the action, usually template names\n",
 		"\n",
 		"Examples:\n",
 		"  update-gitignore list go\n",
+		"  update-gitignore show Global/macOS\n",
 		"  update-gitignore -debug dump Go > .gitignore\n",
+		"  update-gitignore dump -dedupe Go Node VisualStudioCode > .gitignore\n",
+		"  update-gitignore -format json auth status\n",
+		"  update-gitignore check-ignore build/app.log\n",
+		"  update-gitignore diff Node\n",
+		"  update-gitignore changes Go\n",
+		"  update-gitignore update $(update-gitignore detect -names)\n",
+		"  update-gitignore update -recursive\n",
+		"  update-gitignore init -yes JetBrains\n",
+		"  update-gitignore add -after Go Node\n",
+		"  UPDATE_GITIGNORE_TIMEOUT=1m update-gitignore config show\n",
+		"  update-gitignore help dump\n",
 		"\n",
 		"Flags:\n",
+		usageLine("-C string", "run as if started in this directory (default \".\")"),
 		usageLine("-debug", "print debug statements to STDERR"),
+		usageLine("-format string", "the output format (text, json, ndjson, table or a Go template for list) (default \"text\")"),
 		usageLine("-repo string", "the template repository to use (default \"github/gitignore\")"),
 		usageLine("-timeout duration", "the max duration for network requests (0 for no timeout) (default 30s)"),
 	)
@@ -101,6 +129,28 @@ func TestState_ParseArguments(t *testing.T) {
 				0,
 			},
 		},
+		{
+			"invalid action flag",
+			&State{App: newApp(nil, "dump", "-names", "Go")},
+			expected{
+				strptr("flag provided but not defined: -names"),
+				"",
+				chain(
+					"flag provided but not defined: -names\n",
+					"usage: update-gitignore [{flags}] dump [{dump flags}] {template}...\n",
+					"\n",
+					"dumps the selected template(s) to STDOUT\n",
+					"\n",
+					"Writes each template as a managed block, in the order given, without touching .gitignore.\n",
+					"\n",
+					"Flags:\n",
+					usageLine("-dedupe", "drop patterns duplicated by an earlier template"),
+				),
+				false,
+				"",
+				0,
+			},
+		},
 		{
 			"debug flag",
 			&State{App: newApp(nil, "-debug", "list")},
@@ -149,6 +199,18 @@ func TestState_ParseArguments(t *testing.T) {
 				time.Second * 30,
 			},
 		},
+		{
+			"invalid format",
+			&State{App: newApp(nil, "-format", "xml", "list")},
+			expected{
+				strptr("invalid format"),
+				"",
+				"",
+				false,
+				"github/gitignore",
+				time.Second * 30,
+			},
+		},
 		{
 			"invalid repo",
 			&State{App: newApp(nil, "-repo=invalid", "list")},
@@ -181,24 +243,28 @@ func TestState_ParseArguments(t *testing.T) {
 
 func TestState_Command(t *testing.T) {
 	cases := []struct {
-		name  string
-		state *State
-		err   *string
+		name     string
+		state    *State
+		err      *string
+		exitcode ExitStatus
 	}{
 		{
 			"dump",
 			&State{App: newApp(nil, "dump")},
 			nil,
+			2,
 		},
 		{
 			"list",
-			&State{App: newApp(nil, "list")},
+			newState(nil, "valid", "list"),
 			nil,
+			0,
 		},
 		{
 			"invalid",
 			&State{App: newApp(nil, "invalid")},
 			strptr("unrecognized action invalid"),
+			0,
 		},
 	}
 
@@ -218,7 +284,7 @@ func TestState_Command(t *testing.T) {
 				assert.Equal(t, tt.name, name)
 
 				rv := cmd.Run()
-				assert.Equal(t, ExitStatus(0), rv)
+				assert.Equal(t, tt.exitcode, rv)
 			}
 		})
 	}
@@ -0,0 +1,49 @@
+package state
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"unicode/utf8"
+)
+
+// WriteTable writes the rows as tab separated columns. If pad is set, columns are aligned with spaces instead and
+// lines longer than a positive width are cut short with an ellipsis.
+func WriteTable(w io.Writer, rows [][]string, pad bool, width int) error {
+	var buf bytes.Buffer
+
+	if !pad {
+		for _, row := range rows {
+			buf.WriteString(strings.Join(row, "\t"))
+			buf.WriteByte('\n')
+		}
+		_, err := buf.WriteTo(w)
+		return err
+	}
+
+	tw := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	for _, row := range rows {
+		if _, err := io.WriteString(tw, strings.Join(row, "\t")+"\n"); err != nil {
+			return err
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	for _, line := range strings.SplitAfter(buf.String(), "\n") {
+		line = strings.TrimRight(line, " \n")
+		if line == "" {
+			continue
+		}
+		if width > 0 && utf8.RuneCountInString(line) > width {
+			line = strings.TrimRight(string([]rune(line)[:width-1]), " ") + "…"
+		}
+		if _, err := io.WriteString(w, line+"\n"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
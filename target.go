@@ -0,0 +1,65 @@
+package state
+
+import (
+	"flag"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Targets are the files update can manage, selected with -target. The targets after global are written in the
+// syntax of another ignore file, see Conversions.
+var Targets = []string{"gitignore", "exclude", "global", "dockerignore", "npmignore", "helmignore", "gcloudignore"}
+
+// targetFlags declares the flag selecting the file to manage.
+func (s *State) targetFlags(fs *flag.FlagSet) {
+	fs.StringVar(&s.target, "target", "gitignore", "the file to manage: gitignore, exclude (.git/info/exclude), global (core.excludesFile), "+
+		"or dockerignore, npmignore, helmignore or gcloudignore converted from the templates")
+}
+
+// TargetFile returns the name of the file managed for the target, relative to the working directory unless it is
+// absolute.
+func (s *State) TargetFile(target string) (string, error) {
+	switch target {
+	case "", "gitignore":
+		return GitignoreFile, nil
+	case "exclude":
+		out, err := s.git("rev-parse", "--git-path", "info/exclude")
+		if _, ok := err.(*exec.ExitError); ok {
+			return "", fmt.Errorf("-target exclude: %s is not in a git repository", s.dir)
+		}
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(out)), nil
+	case "global":
+		out, err := s.git("config", "--path", "--get", "core.excludesFile")
+		if _, ok := err.(*exec.ExitError); !ok && err != nil {
+			return "", err
+		}
+		if name := strings.TrimSpace(string(out)); err == nil && name != "" {
+			return name, nil
+		}
+
+		// git's default when core.excludesFile is unset
+		dir := s.configDir()
+		if dir == "" {
+			return "", fmt.Errorf("-target global: core.excludesFile is unset and neither XDG_CONFIG_HOME nor HOME is set")
+		}
+		return filepath.Join(dir, "git", "ignore"), nil
+	default:
+		if c := Conversions[target]; c != nil {
+			return c.File, nil
+		}
+		return "", fmt.Errorf("unknown target %s; expected one of %s", target, strings.Join(Targets, ", "))
+	}
+}
+
+// diffName labels the file in a diff, prefixing relative names like git does.
+func diffName(prefix, name string) string {
+	if filepath.IsAbs(name) {
+		return name
+	}
+	return prefix + filepath.ToSlash(name)
+}
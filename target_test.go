@@ -0,0 +1,113 @@
+package state
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestState_TargetFile(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	var (
+		repo    = filepath.Join(dir, "repo")
+		home    = filepath.Join(dir, "home")
+		other   = filepath.Join(dir, "other")
+		xdg     = filepath.Join(dir, "xdg")
+		homeEnv = "HOME=" + home
+	)
+	for _, d := range []string{repo, home, other} {
+		require.NoError(t, os.MkdirAll(d, 0755))
+	}
+	runGit(t, repo, "init", "-q")
+	writeFile(t, filepath.Join(other, ".gitconfig"), "[core]\n\texcludesFile = ~/ignores\n")
+
+	cases := []struct {
+		name   string
+		env    []string
+		dir    string
+		target string
+		file   string
+		err    *string
+	}{
+		{"gitignore", nil, repo, "gitignore", ".gitignore", nil},
+		{"exclude", nil, repo, "exclude", filepath.Join(".git", "info", "exclude"), nil},
+		{"exclude outside a repository", nil, dir, "exclude", "", strptr("-target exclude: " + dir + " is not in a git repository")},
+		{"global", []string{"HOME=" + other, "GIT_CONFIG_GLOBAL=" + filepath.Join(other, ".gitconfig")}, repo, "global", filepath.Join(other, "ignores"), nil},
+		{"global xdg", []string{homeEnv, "XDG_CONFIG_HOME=" + xdg}, repo, "global", filepath.Join(xdg, "git", "ignore"), nil},
+		{"global home", []string{homeEnv}, repo, "global", filepath.Join(home, ".config", "git", "ignore"), nil},
+		{"global without home", nil, repo, "global", "", strptr("-target global: core.excludesFile is unset and neither XDG_CONFIG_HOME nor HOME is set")},
+		{"dockerignore", nil, repo, "dockerignore", ".dockerignore", nil},
+		{"unknown", nil, repo, "info", "", strptr("unknown target info; expected one of gitignore, exclude, global, dockerignore, npmignore, helmignore, gcloudignore")},
+	}
+
+	t.Parallel()
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			env := append([]string{"GIT_CONFIG_NOSYSTEM=1", "GIT_CEILING_DIRECTORIES=" + dir}, tt.env...)
+			s := newState(env, "valid", "-C", tt.dir, "update")
+			defer s.Logger().ShutdownLoggers()
+			require.NoError(t, s.ParseArguments())
+
+			file, err := s.TargetFile(tt.target)
+			errEquals(t, tt.err, err)
+			assert.Equal(t, tt.file, file)
+		})
+	}
+}
+
+func TestUpdateCommand_Target(t *testing.T) {
+	cases := []struct {
+		name   string
+		target string
+		file   func(dir string) string
+	}{
+		{"exclude", "exclude", func(dir string) string { return filepath.Join(dir, ".git", "info", "exclude") }},
+		{"global", "global", func(dir string) string { return filepath.Join(dir, "xdg", "git", "ignore") }},
+	}
+
+	t.Parallel()
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			dir, cleanup := tempDir(t)
+			defer cleanup()
+
+			runGit(t, dir, "init", "-q")
+			writeFile(t, filepath.Join(dir, ".gitignore"), "/build\n")
+			lock := "{\"version\": 1, \"repo\": \"github/gitignore\", \"ref\": \"master\", \"commit\": \"0000\"}\n"
+			writeFile(t, filepath.Join(dir, LockFile), lock)
+
+			env := []string{"GIT_CONFIG_NOSYSTEM=1", "HOME=" + dir, "XDG_CONFIG_HOME=" + filepath.Join(dir, "xdg")}
+			s := newState(env, "valid", "-C", dir, "update", "-target", tt.target, "Global/macOS")
+			require.NoError(t, s.ParseArguments())
+
+			cmd, err := s.Command()
+			require.NoError(t, err)
+			assert.Equal(t, ExitStatus(0), cmd.Run())
+			require.NoError(t, s.Logger().ShutdownLoggers())
+			assert.Contains(t, s.Stderr.(*bytes.Buffer).String(), "adding macOS")
+
+			buf, err := ioutil.ReadFile(tt.file(dir))
+			require.NoError(t, err)
+			assert.Contains(t, string(buf), "# BEGIN update-gitignore: macOS repo=github/gitignore path=Global/macOS.gitignore sha=135767fc075ec33f7f9966fb28968113e32b697e\n# General\n.DS_Store\n")
+			assert.True(t, strings.HasSuffix(string(buf), "\n.apdisk\n# END update-gitignore: macOS\n"), string(buf))
+
+			// the shared files are unchanged
+			buf, err = ioutil.ReadFile(filepath.Join(dir, ".gitignore"))
+			require.NoError(t, err)
+			assert.Equal(t, "/build\n", string(buf))
+			buf, err = ioutil.ReadFile(filepath.Join(dir, LockFile))
+			require.NoError(t, err)
+			assert.Equal(t, lock, string(buf))
+		})
+	}
+}
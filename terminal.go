@@ -0,0 +1,33 @@
+package state
+
+import (
+	"io"
+	"os"
+	"strconv"
+)
+
+// isTerminal reports whether the writer is a terminal rather than a file or pipe.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// terminalWidth returns the number of columns of the terminal, preferring $COLUMNS, or 0 if it is unknown.
+func (s *State) terminalWidth(w io.Writer) int {
+	if columns, ok := s.LookupEnv("COLUMNS"); ok {
+		if n, err := strconv.Atoi(columns); err == nil && n > 0 {
+			return n
+		}
+	}
+
+	if f, ok := w.(*os.File); ok && isTerminal(w) {
+		return windowWidth(f)
+	}
+
+	return 0
+}
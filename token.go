@@ -0,0 +1,203 @@
+package state
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// DefaultHost is the host used to look up credentials for the GitHub API.
+const DefaultHost = "github.com"
+
+type (
+	// TokenProvider looks up a GitHub token for a host from a single source.
+	TokenProvider interface {
+		// Name identifies the provider in log output.
+		Name() string
+		// Token returns the token for the host or ErrTokenNotFound.
+		Token(s *State, host string) (string, error)
+	}
+
+	envTokenProvider           []string
+	ghConfigTokenProvider      struct{}
+	gitCredentialTokenProvider struct{}
+	netrcTokenProvider         struct{}
+)
+
+// DefaultTokenProviders returns the chain of providers consulted, in order, by Client.Token. Each call returns a new
+// slice, so callers may change it.
+func DefaultTokenProviders() []TokenProvider {
+	return []TokenProvider{
+		envTokenProvider{"GITHUB_TOKEN", "GH_TOKEN"},
+		ghConfigTokenProvider{},
+		gitCredentialTokenProvider{},
+		netrcTokenProvider{},
+	}
+}
+
+// LookupToken consults each provider in order and returns the first token found along with the name of the
+// provider that supplied it.
+func LookupToken(s *State, host string, providers []TokenProvider) (string, string, error) {
+	for _, p := range providers {
+		token, err := p.Token(s, host)
+		if err == nil && token != "" {
+			return token, p.Name(), nil
+		}
+
+		if err != nil && err != ErrTokenNotFound {
+			s.Logger().Debugf("token provider %s: %v", p.Name(), err)
+		}
+	}
+
+	return "", "", ErrTokenNotFound
+}
+
+func (p envTokenProvider) Name() string { return "environment" }
+
+func (p envTokenProvider) Token(s *State, host string) (string, error) {
+	for _, key := range p {
+		if value, _ := s.LookupEnv(key); value != "" {
+			return value, nil
+		}
+	}
+
+	return "", ErrTokenNotFound
+}
+
+func (ghConfigTokenProvider) Name() string { return "gh config" }
+
+func (ghConfigTokenProvider) Token(s *State, host string) (string, error) {
+	dir, _ := s.LookupEnv("GH_CONFIG_DIR")
+	if dir == "" {
+		dir = s.configDir()
+		if dir == "" {
+			return "", ErrTokenNotFound
+		}
+		dir = filepath.Join(dir, "gh")
+	}
+
+	buf, err := ioutil.ReadFile(filepath.Join(dir, "hosts.yml"))
+	if os.IsNotExist(err) {
+		return "", ErrTokenNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+
+	var hosts map[string]struct {
+		OAuthToken string `yaml:"oauth_token"`
+	}
+	if err := yaml.Unmarshal(buf, &hosts); err != nil {
+		return "", err
+	}
+
+	if token := hosts[host].OAuthToken; token != "" {
+		return token, nil
+	}
+
+	return "", ErrTokenNotFound
+}
+
+func (gitCredentialTokenProvider) Name() string { return "git credential" }
+
+func (gitCredentialTokenProvider) Token(s *State, host string) (string, error) {
+	ctx, cancel := s.deadline()
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", "credential", "fill")
+	// never prompt the user, the provider chain should fall through instead
+	cmd.Env = append(append([]string{}, s.Environment...), "GIT_TERMINAL_PROMPT=0", "GCM_INTERACTIVE=never")
+	cmd.Stdin = strings.NewReader("protocol=https\nhost=" + host + "\n\n")
+
+	out, err := cmd.Output()
+	if err != nil {
+		if _, ok := err.(*exec.ExitError); ok {
+			return "", ErrTokenNotFound
+		}
+		return "", err
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "password=") {
+			if token := strings.TrimPrefix(line, "password="); token != "" {
+				return token, nil
+			}
+		}
+	}
+
+	return "", ErrTokenNotFound
+}
+
+func (netrcTokenProvider) Name() string { return "netrc" }
+
+func (netrcTokenProvider) Token(s *State, host string) (string, error) {
+	path, _ := s.LookupEnv("NETRC")
+	if path == "" {
+		home := s.homeDir()
+		if home == "" {
+			return "", ErrTokenNotFound
+		}
+		path = filepath.Join(home, ".netrc")
+	}
+
+	buf, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return "", ErrTokenNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return parseNetrc(buf, host)
+}
+
+// parseNetrc returns the password for the named machine, falling back to the default entry.
+func parseNetrc(buf []byte, host string) (string, error) {
+	var (
+		fields   = strings.Fields(string(buf))
+		machine  string
+		inEntry  bool
+		fallback string
+	)
+
+	for i := 0; i < len(fields); i++ {
+		switch fields[i] {
+		case "machine":
+			if i+1 < len(fields) {
+				i++
+				machine = fields[i]
+				inEntry = true
+			}
+		case "default":
+			machine = ""
+			inEntry = true
+		case "macdef":
+			// macro definitions run until a blank line which Fields has already collapsed; stop parsing
+			inEntry = false
+		case "password":
+			if i+1 < len(fields) && inEntry {
+				i++
+				if machine == host {
+					return fields[i], nil
+				}
+				if machine == "" && fallback == "" {
+					fallback = fields[i]
+				}
+			}
+		}
+	}
+
+	if fallback != "" {
+		return fallback, nil
+	}
+
+	return "", ErrTokenNotFound
+}
@@ -0,0 +1,147 @@
+package state
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func tempDir(tb testing.TB) (string, func()) {
+	dir, err := ioutil.TempDir("", "update-gitignore")
+	require.NoError(tb, err)
+	return dir, func() { os.RemoveAll(dir) }
+}
+
+func writeFile(tb testing.TB, path, content string) {
+	require.NoError(tb, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(tb, ioutil.WriteFile(path, []byte(content), 0644))
+}
+
+func TestLookupToken(t *testing.T) {
+	home, cleanup := tempDir(t)
+	defer cleanup()
+
+	writeFile(t, filepath.Join(home, ".config", "gh", "hosts.yml"), "github.com:\n    oauth_token: ghtoken\n    user: demosdemon\n")
+	writeFile(t, filepath.Join(home, ".netrc"), "machine example.com login a password b\nmachine github.com login demosdemon password netrctoken\n")
+
+	helper := []string{
+		"GIT_CONFIG_NOSYSTEM=1",
+		"GIT_CONFIG_COUNT=1",
+		"GIT_CONFIG_KEY_0=credential.helper",
+		"GIT_CONFIG_VALUE_0=!f() { echo username=demosdemon; echo password=gittoken; }; f",
+	}
+
+	cases := []struct {
+		name        string
+		environment []string
+		providers   []TokenProvider
+		token       string
+		source      string
+		err         *string
+	}{
+		{
+			"github token",
+			[]string{"GITHUB_TOKEN=envtoken", "GH_TOKEN=other", "HOME=" + home},
+			DefaultTokenProviders(),
+			"envtoken",
+			"environment",
+			nil,
+		},
+		{
+			"gh token",
+			[]string{"GH_TOKEN=other", "HOME=" + home},
+			DefaultTokenProviders(),
+			"other",
+			"environment",
+			nil,
+		},
+		{
+			"gh config",
+			[]string{"HOME=" + home},
+			DefaultTokenProviders(),
+			"ghtoken",
+			"gh config",
+			nil,
+		},
+		{
+			"git credential",
+			append([]string{"HOME=" + home}, helper...),
+			[]TokenProvider{gitCredentialTokenProvider{}, netrcTokenProvider{}},
+			"gittoken",
+			"git credential",
+			nil,
+		},
+		{
+			"netrc",
+			[]string{"HOME=" + home, "GIT_CONFIG_NOSYSTEM=1"},
+			[]TokenProvider{gitCredentialTokenProvider{}, netrcTokenProvider{}},
+			"netrctoken",
+			"netrc",
+			nil,
+		},
+		{
+			"none",
+			[]string{"HOME=" + filepath.Join(home, "missing"), "GIT_CONFIG_NOSYSTEM=1"},
+			DefaultTokenProviders(),
+			"",
+			"",
+			strptr("token not found"),
+		},
+	}
+
+	t.Parallel()
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			s := &State{App: newApp(tt.environment)}
+			defer s.Logger().ShutdownLoggers()
+
+			token, source, err := LookupToken(s, DefaultHost, tt.providers)
+			assert.Equal(t, tt.token, token)
+			assert.Equal(t, tt.source, source)
+			errEquals(t, tt.err, err)
+		})
+	}
+}
+
+func TestParseNetrc(t *testing.T) {
+	cases := []struct {
+		name  string
+		netrc string
+		token string
+		err   *string
+	}{
+		{
+			"machine",
+			"machine github.com\n\tlogin demosdemon\n\tpassword secret\n",
+			"secret",
+			nil,
+		},
+		{
+			"default",
+			"machine example.com login a password b\ndefault login c password d\n",
+			"d",
+			nil,
+		},
+		{
+			"missing",
+			"machine example.com login a password b\n",
+			"",
+			strptr("token not found"),
+		},
+	}
+
+	t.Parallel()
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := parseNetrc([]byte(tt.netrc), DefaultHost)
+			assert.Equal(t, tt.token, token)
+			errEquals(t, tt.err, err)
+		})
+	}
+}
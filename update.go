@@ -0,0 +1,468 @@
+package state
+
+import (
+	"flag"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+type (
+	updateCommand State
+	diffCommand   State
+
+	// Update is the result of refreshing the managed blocks of a gitignore file.
+	Update struct {
+		Name    string
+		Old     *Gitignore
+		New     *Gitignore
+		Catalog *Catalog
+
+		// Added are the templates appended as new blocks, Updated the blocks whose template changed upstream and
+		// Removed the blocks deleted because the manifest no longer lists them.
+		Added   []string
+		Updated []string
+		Removed []string
+
+		// Merged are the updated blocks whose local edits were kept and Conflicts the edits that clash with
+		// upstream changes.
+		Merged    []string
+		Conflicts []*Conflict
+
+		// NewlyIgnored are the tracked files ignored by the new file but not by the old one.
+		NewlyIgnored []IgnoreMatch
+
+		// ConversionNotes are the rules of each block whose meaning differs in a converted file, by block name.
+		ConversionNotes map[string][]ConversionNote
+	}
+)
+
+func (c *updateCommand) GetName() string { return "update" }
+
+func (c *updateCommand) Flags(fs *flag.FlagSet) {
+	(*State)(c).writeFlags(fs)
+	(*State)(c).targetFlags(fs)
+	fs.BoolVar(&c.conflictMarkers, "conflict-markers", false, "write conflicting local edits between git-style markers instead of failing")
+	fs.BoolVar(&c.recursive, "recursive", false, "also update the .gitignore of each project in subdirectories, found by its manifest or marker files")
+}
+
+// Run refreshes the managed blocks in the local gitignore file, appending blocks for named templates that aren't
+// present yet, and writes the result. Without templates, a manifest in the working directory is used if there is
+// one. An existing lockfile is updated to match. Local edits to a block are merged with the upstream changes;
+// if they conflict, nothing is written unless -conflict-markers is set, and 1 is returned either way.
+func (c *updateCommand) Run() ExitStatus {
+	s := (*State)(c)
+	logger := s.Logger()
+
+	if s.recursive {
+		return c.runRecursive()
+	}
+
+	u, err := s.planUpdate()
+	if err != nil {
+		logger.Error(err.Error())
+		return 1
+	}
+
+	s.reportUpdate(u)
+
+	if len(u.Conflicts) > 0 && !s.conflictMarkers {
+		logger.Errorf("%s: local edits conflict with upstream changes; resolve them or use -conflict-markers", u.Name)
+		return 1
+	}
+
+	if u.Old.String() == u.New.String() {
+		logger.Infof("%s is up to date", u.Name)
+		if err := s.updateLock(u); err != nil {
+			logger.Error(err.Error())
+			return 1
+		}
+		return 0
+	}
+
+	if err := s.WriteGitignore(u.Name, u.New); err != nil {
+		logger.Error(err.Error())
+		return 1
+	}
+
+	if err := s.updateLock(u); err != nil {
+		logger.Error(err.Error())
+		return 1
+	}
+
+	if len(u.Conflicts) > 0 {
+		logger.Errorf("%s: wrote %d conflicts between markers", u.Name, len(u.Conflicts))
+		return 1
+	}
+
+	return 0
+}
+
+// updateLock rewrites the lockfile to match the updated gitignore file if there is a lockfile that doesn't already
+// record its blocks. Other targets aren't locked since they aren't shared.
+func (s *State) updateLock(u *Update) error {
+	if s.target != "" && s.target != "gitignore" {
+		return nil
+	}
+
+	old, err := s.ReadLock()
+	if err != nil || old == nil {
+		return err
+	}
+
+	lock, err := NewLock(u.Catalog, u.New)
+	if err != nil {
+		return err
+	}
+
+	// upstream moving on alone doesn't change the blocks
+	cmp := *lock
+	cmp.Commit = old.Commit
+	if reflect.DeepEqual(&cmp, old) {
+		return nil
+	}
+
+	return s.WriteLock(lock)
+}
+
+func (c *diffCommand) GetName() string { return "diff" }
+
+func (c *diffCommand) Flags(fs *flag.FlagSet) {
+	(*State)(c).targetFlags(fs)
+}
+
+// Run prints the changes update would make as a unified diff. Returns 0 if there are no changes, 1 if there are
+// and 2 on error.
+func (c *diffCommand) Run() ExitStatus {
+	s := (*State)(c)
+	logger := s.Logger()
+
+	u, err := s.planUpdate()
+	if err != nil {
+		logger.Error(err.Error())
+		return 2
+	}
+
+	s.reportUpdate(u)
+
+	diff := UnifiedDiff(diffName("a/", u.Name), diffName("b/", u.Name), u.Old.String(), u.New.String(), 3)
+	if diff == "" {
+		return 0
+	}
+
+	if _, err := fmt.Fprint(s.Stdout, diff); err != nil {
+		logger.Error(err.Error())
+		return 2
+	}
+
+	return 1
+}
+
+// reportUpdate logs what the update changes, conflicts between the new rules and tracked files that become ignored.
+func (s *State) reportUpdate(u *Update) {
+	logger := s.Logger()
+
+	for _, name := range u.Added {
+		logger.Infof("adding %s", name)
+	}
+	for _, name := range u.Updated {
+		logger.Infof("updating %s", name)
+	}
+	for _, name := range u.Removed {
+		logger.Infof("removing %s", name)
+	}
+	for _, name := range u.Merged {
+		logger.Infof("merging local edits to %s", name)
+	}
+	for _, c := range u.Conflicts {
+		logger.Warn(c.String())
+	}
+
+	// only the blocks being written are converted again
+	for _, name := range append(append([]string{}, u.Added...), u.Updated...) {
+		for _, w := range conversionWarnings(u.Name, name, u.ConversionNotes[name]) {
+			logger.Warn(w)
+		}
+	}
+
+	if m, err := NewGitignoreMatcher(u.New, u.Name); err == nil {
+		for _, d := range AnalyzeConflicts(m.Rules()) {
+			logger.Warn(d.String())
+		}
+	}
+
+	for _, m := range u.NewlyIgnored {
+		template := m.Template
+		if template == "" {
+			template = "local rules"
+		}
+		logger.Warnf("tracked file %s would be ignored by %q (%s:%d) from %s", m.Path, m.Pattern, m.Source, m.Line, template)
+	}
+}
+
+// PlanUpdate computes the new contents of the named gitignore file, relative to the working directory. Each named
+// template replaces the block of the same name or is appended as a new block. If no templates are named, every
+// managed block from the configured repository is refreshed.
+func (s *State) PlanUpdate(name string, templates []string) (*Update, error) {
+	cat, err := s.Catalog()
+	if err != nil {
+		return nil, err
+	}
+
+	return s.planTemplates(name, cat, templates)
+}
+
+// planTemplates computes the new contents of the named gitignore file like PlanUpdate, taking the templates from the
+// catalog.
+func (s *State) planTemplates(name string, cat *Catalog, templates []string) (*Update, error) {
+	old, err := s.ReadGitignore(name)
+	if err != nil {
+		return nil, err
+	}
+
+	blocks, err := old.Blocks()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+
+	if len(templates) == 0 {
+		for _, b := range blocks {
+			if b.Path == "" {
+				// not from a template, e.g. the local patterns of a manifest
+				continue
+			}
+			if b.Repo != "" && b.Repo != cat.Repo {
+				s.Logger().Warnf("block %s is from %s, not %s; leaving it unchanged", b.Name, b.Repo, cat.Repo)
+				continue
+			}
+			templates = append(templates, b.Path)
+		}
+		if len(templates) == 0 {
+			return nil, fmt.Errorf("%s has no managed blocks to update", name)
+		}
+	}
+
+	resolved, err := cat.Resolve(templates...)
+	if err != nil {
+		return nil, err
+	}
+
+	sections, err := s.Sections(cat, resolved)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.plan(name, cat, sections, false)
+}
+
+// planUpdate plans the update of the -target file requested on the command line: the named templates, or if there
+// are none, the templates declared by the manifest if there is one. The manifest isn't used for the exclude and
+// global targets, which hold personal rules rather than the project's.
+func (s *State) planUpdate() (*Update, error) {
+	name, err := s.TargetFile(s.target)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(s.templates) == 0 && s.target != "exclude" && s.target != "global" {
+		m, err := s.ReadManifest()
+		if err != nil {
+			return nil, err
+		}
+		if m != nil && Conversions[s.target] != nil {
+			return s.planManifest(m, name, make(map[string]*Catalog))
+		}
+		if m != nil {
+			return s.PlanManifest(m)
+		}
+	}
+
+	return s.PlanUpdate(name, s.templates)
+}
+
+// plan applies the sections to the named gitignore file. If prune is set, blocks without a section are removed.
+func (s *State) plan(name string, cat *Catalog, sections []*Section, prune bool) (*Update, error) {
+	old, err := s.ReadGitignore(name)
+	if err != nil {
+		return nil, err
+	}
+
+	u := &Update{Name: name, Old: old, Catalog: cat}
+
+	if c := Conversions[s.target]; c != nil {
+		sections, u.ConversionNotes = convertSections(c, sections)
+	}
+
+	g := old
+	if prune {
+		blocks, err := g.Blocks()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+
+		var stale []string
+		for _, b := range blocks {
+			if !hasSection(sections, b.Name) {
+				stale = append(stale, b.Name)
+			}
+		}
+
+		if len(stale) > 0 {
+			if g, u.Removed, err = RemoveBlocks(g, stale...); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	blocks, err := g.Blocks()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+
+	merged, err := s.mergeBlocks(u, g, blocks, sections)
+	if err != nil {
+		return nil, err
+	}
+
+	u.New = applySections(u, g, blocks, sections, merged)
+
+	if err := s.findNewlyIgnored(u); err != nil {
+		return nil, err
+	}
+
+	return u, nil
+}
+
+func hasSection(sections []*Section, name string) bool {
+	for _, sec := range sections {
+		if sec.Template.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeBlocks merges the local edits to the blocks being replaced by the sections with the upstream changes,
+// returning the merged lines of each block with edits keyed by name.
+func (s *State) mergeBlocks(u *Update, g *Gitignore, blocks []*Block, sections []*Section) (map[string][]string, error) {
+	cl, err := s.clientFor(u.Catalog.Repo)
+	if err != nil {
+		return nil, err
+	}
+
+	merged := make(map[string][]string)
+	for _, sec := range sections {
+		for _, b := range blocks {
+			if b.Name != sec.Template.Name || b.Path == "" || (b.Repo != "" && b.Repo != u.Catalog.Repo) {
+				continue
+			}
+			if lines, conflicts := s.mergeBlock(cl, g, b, sec); lines != nil {
+				merged[b.Name] = lines
+				u.Merged = append(u.Merged, b.Name)
+				u.Conflicts = append(u.Conflicts, conflicts...)
+			}
+		}
+	}
+
+	return merged, nil
+}
+
+// applySections replaces the blocks of g matching the sections by name, with the merged lines if there are any,
+// and appends the rest, recording the changes.
+func applySections(u *Update, g *Gitignore, blocks []*Block, sections []*Section, merged map[string][]string) *Gitignore {
+	var (
+		byBegin  = make(map[int]*Section)
+		appended []*Section
+	)
+
+	for _, sec := range sections {
+		var found *Block
+		for _, b := range blocks {
+			if b.Name == sec.Template.Name {
+				found = b
+				break
+			}
+		}
+
+		if found == nil {
+			appended = append(appended, sec)
+			u.Added = append(u.Added, sec.Template.Name)
+			continue
+		}
+
+		byBegin[found.Begin] = sec
+		if found.SHA != sec.Template.SHA {
+			u.Updated = append(u.Updated, sec.Template.Name)
+		}
+	}
+
+	var lines []string
+	for idx := 0; idx < len(g.Lines); idx++ {
+		sec, ok := byBegin[idx]
+		if !ok {
+			lines = append(lines, g.Lines[idx].Text)
+			continue
+		}
+
+		if m, ok := merged[sec.Template.Name]; ok {
+			lines = append(lines, m...)
+		} else {
+			lines = append(lines, sec.Lines()...)
+		}
+		for _, b := range blocks {
+			if b.Begin == idx {
+				idx = b.End
+				break
+			}
+		}
+	}
+
+	for _, sec := range appended {
+		if len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) != "" {
+			lines = append(lines, "")
+		}
+		lines = append(lines, sec.Lines()...)
+	}
+
+	if len(lines) == 0 {
+		return new(Gitignore)
+	}
+
+	return ParseGitignoreString(strings.Join(lines, "\n") + "\n")
+}
+
+// findNewlyIgnored records the tracked files that the new file ignores and the old one doesn't. Converted files
+// aren't read by git, so they are skipped.
+func (s *State) findNewlyIgnored(u *Update) error {
+	if Conversions[s.target] != nil {
+		return nil
+	}
+
+	tracked, err := s.TrackedFiles()
+	if err != nil || len(tracked) == 0 {
+		return err
+	}
+
+	before, err := NewGitignoreMatcher(u.Old, u.Name)
+	if err != nil {
+		return err
+	}
+
+	after, err := NewGitignoreMatcher(u.New, u.Name)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range tracked {
+		if after.Ignored(name, false) && !before.Ignored(name, false) {
+			u.NewlyIgnored = append(u.NewlyIgnored, newIgnoreMatch(name, after.Match(name, false)))
+		}
+	}
+
+	return nil
+}
+
+// WriteGitignore writes the gitignore file, relative to the working directory, keeping the mode of an existing file.
+func (s *State) WriteGitignore(name string, g *Gitignore) error {
+	return s.writeFile(name, []byte(g.String()), 0644)
+}
@@ -0,0 +1,180 @@
+package state
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const staleVSCode = "# BEGIN update-gitignore: VisualStudioCode repo=github/gitignore path=Global/VisualStudioCode.gitignore sha=0000000000000000000000000000000000000000\n" +
+	".vscode/*\n" +
+	"# END update-gitignore: VisualStudioCode\n"
+
+func runGit(tb testing.TB, dir string, args ...string) {
+	tb.Helper()
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	out, err := cmd.CombinedOutput()
+	require.NoError(tb, err, string(out))
+}
+
+func TestDiffCommand_Run(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	writeFile(t, filepath.Join(dir, ".gitignore"), "/secrets\n\n"+staleVSCode)
+	writeFile(t, filepath.Join(dir, "notes.txt~"), "")
+	writeFile(t, filepath.Join(dir, "main.go"), "")
+	runGit(t, dir, "init", "-q")
+	runGit(t, dir, "add", ".")
+
+	s := newState(nil, "valid", "-C", dir, "diff", "Linux")
+	require.NoError(t, s.ParseArguments())
+
+	cmd, err := s.Command()
+	require.NoError(t, err)
+	assert.Equal(t, "diff", cmd.GetName())
+	assert.Equal(t, ExitStatus(1), cmd.Run())
+	require.NoError(t, s.Logger().ShutdownLoggers())
+
+	stdout := s.Stdout.(*bytes.Buffer).String()
+	assert.Contains(t, stdout, chain(
+		"--- a/.gitignore\n",
+		"+++ b/.gitignore\n",
+		"@@ -3,3 +3,19 @@\n",
+		" # BEGIN update-gitignore: VisualStudioCode",
+	))
+	assert.Contains(t, stdout, "+# BEGIN update-gitignore: Linux repo=github/gitignore path=Global/Linux.gitignore sha=b56bf65d85583b03eeccfaa2a927084583a33e91\n+*~\n")
+	assert.Contains(t, s.Stderr.(*bytes.Buffer).String(), `tracked file notes.txt~ would be ignored by "*~" (.gitignore:8) from Linux`)
+
+	// diff doesn't write the file
+	buf, err := ioutil.ReadFile(filepath.Join(dir, ".gitignore"))
+	require.NoError(t, err)
+	assert.Equal(t, "/secrets\n\n"+staleVSCode, string(buf))
+}
+
+func TestUpdateCommand_Run(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	path := filepath.Join(dir, ".gitignore")
+	writeFile(t, path, "/secrets\n\n"+staleVSCode+"\nlocal.txt\n")
+	require.NoError(t, os.Chmod(path, 0600))
+
+	s := newState(nil, "valid", "-C", dir, "update")
+	require.NoError(t, s.ParseArguments())
+	require.NoError(t, s.WriteLock(&Lock{Version: LockVersion, Repo: "github/gitignore", Ref: "master", Commit: "0000"}))
+
+	cmd, err := s.Command()
+	require.NoError(t, err)
+	assert.Equal(t, "update", cmd.GetName())
+	assert.Equal(t, ExitStatus(0), cmd.Run())
+	require.NoError(t, s.Logger().ShutdownLoggers())
+	assert.Contains(t, s.Stderr.(*bytes.Buffer).String(), "updating VisualStudioCode")
+
+	buf, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, chain(
+		"/secrets\n",
+		"\n",
+		"# BEGIN update-gitignore: VisualStudioCode repo=github/gitignore path=Global/VisualStudioCode.gitignore sha=0511e2b51f0d42d1dff69f4ed5df03c6649ca356\n",
+		".vscode/*\n",
+		"!.vscode/settings.json\n",
+		"!.vscode/tasks.json\n",
+		"!.vscode/launch.json\n",
+		"!.vscode/extensions.json\n",
+		"# END update-gitignore: VisualStudioCode\n",
+		"\n",
+		"local.txt\n",
+	), string(buf))
+
+	st, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), st.Mode().Perm())
+
+	lock, err := s.ReadLock()
+	require.NoError(t, err)
+	assert.Equal(t, "56e3f5a7b2a67413a1d3e33fceb8100898015a2e", lock.Commit)
+	assert.Equal(t, []LockedTemplate{{
+		Name: "VisualStudioCode",
+		Path: "Global/VisualStudioCode.gitignore",
+		SHA:  "0511e2b51f0d42d1dff69f4ed5df03c6649ca356",
+	}}, lock.Templates)
+
+	// a second update is a no-op
+	s = newState(nil, "valid", "-C", dir, "diff")
+	defer s.Logger().ShutdownLoggers()
+	require.NoError(t, s.ParseArguments())
+
+	cmd, err = s.Command()
+	require.NoError(t, err)
+	assert.Equal(t, ExitStatus(0), cmd.Run())
+	assert.Empty(t, s.Stdout.(*bytes.Buffer).String())
+}
+
+func TestUpdateCommand_RepairLock(t *testing.T) {
+	t.Parallel()
+
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	writeFile(t, filepath.Join(dir, ".gitignore"), chain(
+		"# BEGIN update-gitignore: VisualStudioCode repo=github/gitignore path=Global/VisualStudioCode.gitignore sha=0511e2b51f0d42d1dff69f4ed5df03c6649ca356\n",
+		".vscode/*\n",
+		"!.vscode/settings.json\n",
+		"!.vscode/tasks.json\n",
+		"!.vscode/launch.json\n",
+		"!.vscode/extensions.json\n",
+		"# END update-gitignore: VisualStudioCode\n",
+	))
+
+	locked := []LockedTemplate{{
+		Name: "VisualStudioCode",
+		Path: "Global/VisualStudioCode.gitignore",
+		SHA:  "0511e2b51f0d42d1dff69f4ed5df03c6649ca356",
+	}}
+
+	run := func(lock *Lock) *Lock {
+		s := newState(nil, "valid", "-C", dir, "update")
+		require.NoError(t, s.ParseArguments())
+		require.NoError(t, s.WriteLock(lock))
+
+		cmd, err := s.Command()
+		require.NoError(t, err)
+		assert.Equal(t, ExitStatus(0), cmd.Run())
+		require.NoError(t, s.Logger().ShutdownLoggers())
+		assert.Contains(t, s.Stderr.(*bytes.Buffer).String(), ".gitignore is up to date")
+
+		lock, err = s.ReadLock()
+		require.NoError(t, err)
+		return lock
+	}
+
+	// the blocks are up to date but the lockfile doesn't record them
+	lock := run(&Lock{Version: LockVersion, Repo: "github/gitignore", Ref: "master", Commit: "0000"})
+	assert.Equal(t, "56e3f5a7b2a67413a1d3e33fceb8100898015a2e", lock.Commit)
+	assert.Equal(t, locked, lock.Templates)
+
+	// a lockfile recording the blocks is left alone
+	lock = run(&Lock{Version: LockVersion, Repo: "github/gitignore", Ref: "master", Commit: "0000", Templates: locked})
+	assert.Equal(t, "0000", lock.Commit)
+}
+
+func TestUpdateCommand_NoBlocks(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	s := newState(nil, "valid", "-C", dir, "update")
+	require.NoError(t, s.ParseArguments())
+
+	cmd, err := s.Command()
+	require.NoError(t, err)
+	assert.Equal(t, ExitStatus(1), cmd.Run())
+	require.NoError(t, s.Logger().ShutdownLoggers())
+	assert.Contains(t, s.Stderr.(*bytes.Buffer).String(), ".gitignore has no managed blocks to update")
+}
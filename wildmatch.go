@@ -0,0 +1,265 @@
+package state
+
+import "strings"
+
+// This is a port of git's wildmatch.c which implements the glob semantics used by gitignore files.
+
+type wildResult int8
+
+const (
+	wildMatch wildResult = iota
+	wildNoMatch
+	wildAbortAll
+	wildAbortToStarStar
+)
+
+// wildmatch reports whether text matches the glob pattern. If pathname is true, wildcards do not match slashes unless
+// they are part of a "**" path component.
+func wildmatch(pattern, text string, pathname bool) bool {
+	return dowild(pattern, text, pathname) == wildMatch
+}
+
+// byteAt emulates reading a NUL-terminated C string.
+func byteAt(s string, i int) byte {
+	if i < len(s) {
+		return s[i]
+	}
+	return 0
+}
+
+func isGlobSpecial(c byte) bool {
+	return c == '*' || c == '?' || c == '[' || c == '\\'
+}
+
+func dowild(pattern, text string, pathname bool) wildResult {
+	p, t := 0, 0
+	for ; p < len(pattern); p, t = p+1, t+1 {
+		pc := pattern[p]
+		tc := byteAt(text, t)
+		if tc == 0 && pc != '*' {
+			return wildAbortAll
+		}
+
+		switch pc {
+		case '\\':
+			// literal match with the following character
+			p++
+			if tc != byteAt(pattern, p) {
+				return wildNoMatch
+			}
+			continue
+		default:
+			if tc != pc {
+				return wildNoMatch
+			}
+			continue
+		case '?':
+			if pathname && tc == '/' {
+				return wildNoMatch
+			}
+			continue
+		case '*':
+			var matchSlash bool
+			p++
+			if byteAt(pattern, p) == '*' {
+				prev := p - 2
+				for p++; byteAt(pattern, p) == '*'; p++ {
+				}
+				next := byteAt(pattern, p)
+				if !pathname {
+					// without pathname, "*" is the same as "**"
+					matchSlash = true
+				} else if (prev < 0 || pattern[prev] == '/') &&
+					(next == 0 || next == '/' || (next == '\\' && byteAt(pattern, p+1) == '/')) {
+					// assume "**/" matches nothing and try to match the rest of the pattern, so that foo/**/bar
+					// matches both foo/bar and foo/a/bar
+					if next == '/' && dowild(pattern[p+1:], text[t:], pathname) == wildMatch {
+						return wildMatch
+					}
+					matchSlash = true
+				} else {
+					matchSlash = false
+				}
+			} else {
+				// without pathname, "*" is the same as "**"
+				matchSlash = !pathname
+			}
+
+			if p >= len(pattern) {
+				// trailing "**" matches everything, trailing "*" only if there are no more slashes
+				if !matchSlash && strings.IndexByte(text[t:], '/') >= 0 {
+					return wildNoMatch
+				}
+				return wildMatch
+			} else if !matchSlash && pattern[p] == '/' {
+				// a single asterisk followed by a slash matches the next directory
+				slash := strings.IndexByte(text[t:], '/')
+				if slash < 0 {
+					return wildNoMatch
+				}
+				t += slash
+				// the slash is consumed by the loop
+				continue
+			}
+
+			for {
+				if tc == 0 {
+					break
+				}
+
+				// advance quickly when the asterisk is followed by a literal
+				if !isGlobSpecial(pattern[p]) {
+					pc = pattern[p]
+					for tc = byteAt(text, t); tc != 0 && (matchSlash || tc != '/'); tc = byteAt(text, t) {
+						if tc == pc {
+							break
+						}
+						t++
+					}
+					if tc != pc {
+						return wildNoMatch
+					}
+				}
+
+				if matched := dowild(pattern[p:], text[t:], pathname); matched != wildNoMatch {
+					if !matchSlash || matched != wildAbortToStarStar {
+						return matched
+					}
+				} else if !matchSlash && tc == '/' {
+					return wildAbortToStarStar
+				}
+
+				t++
+				tc = byteAt(text, t)
+			}
+			return wildAbortAll
+		case '[':
+			p++
+			pc = byteAt(pattern, p)
+			if pc == '^' {
+				pc = '!'
+			}
+			negated := pc == '!'
+			if negated {
+				p++
+				pc = byteAt(pattern, p)
+			}
+
+			var prev byte
+			matched := false
+			for {
+				if pc == 0 {
+					return wildAbortAll
+				}
+
+				if pc == '\\' {
+					p++
+					pc = byteAt(pattern, p)
+					if pc == 0 {
+						return wildAbortAll
+					}
+					if tc == pc {
+						matched = true
+					}
+				} else if pc == '-' && prev != 0 && byteAt(pattern, p+1) != 0 && byteAt(pattern, p+1) != ']' {
+					p++
+					pc = pattern[p]
+					if pc == '\\' {
+						p++
+						pc = byteAt(pattern, p)
+						if pc == 0 {
+							return wildAbortAll
+						}
+					}
+					if tc <= pc && tc >= prev {
+						matched = true
+					}
+					pc = 0
+				} else if pc == '[' && byteAt(pattern, p+1) == ':' {
+					p += 2
+					start := p
+					for pc = byteAt(pattern, p); pc != 0 && pc != ']'; pc = byteAt(pattern, p) {
+						p++
+					}
+					if pc == 0 {
+						return wildAbortAll
+					}
+					if p-start-1 < 0 || pattern[p-1] != ':' {
+						// didn't find ":]", treat it like a normal set
+						p = start - 2
+						pc = '['
+						if tc == pc {
+							matched = true
+						}
+					} else {
+						class, ok := charClass(pattern[start:p-1], tc)
+						if !ok {
+							return wildAbortAll
+						}
+						if class {
+							matched = true
+						}
+						pc = 0
+					}
+				} else if tc == pc {
+					matched = true
+				}
+
+				prev = pc
+				p++
+				pc = byteAt(pattern, p)
+				if pc == ']' {
+					break
+				}
+			}
+
+			if matched == negated || (pathname && tc == '/') {
+				return wildNoMatch
+			}
+			continue
+		}
+	}
+
+	if t < len(text) {
+		return wildNoMatch
+	}
+	return wildMatch
+}
+
+// charClass reports whether c is a member of the named POSIX character class. ok is false if the class name is
+// not recognized.
+func charClass(name string, c byte) (member bool, ok bool) {
+	isUpper := 'A' <= c && c <= 'Z'
+	isLower := 'a' <= c && c <= 'z'
+	isDigit := '0' <= c && c <= '9'
+	isPrint := 0x20 <= c && c < 0x7f
+
+	switch name {
+	case "alnum":
+		return isUpper || isLower || isDigit, true
+	case "alpha":
+		return isUpper || isLower, true
+	case "blank":
+		return c == ' ' || c == '\t', true
+	case "cntrl":
+		return c < 0x20 || c == 0x7f, true
+	case "digit":
+		return isDigit, true
+	case "graph":
+		return isPrint && c != ' ', true
+	case "lower":
+		return isLower, true
+	case "print":
+		return isPrint, true
+	case "punct":
+		return isPrint && c != ' ' && !isUpper && !isLower && !isDigit, true
+	case "space":
+		return c == ' ' || ('\t' <= c && c <= '\r'), true
+	case "upper":
+		return isUpper, true
+	case "xdigit":
+		return isDigit || ('a' <= c && c <= 'f') || ('A' <= c && c <= 'F'), true
+	default:
+		return false, false
+	}
+}
@@ -0,0 +1,149 @@
+package state
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// BackupSuffix is appended to the name of a file to back it up.
+const BackupSuffix = ".bak"
+
+// writeFlags declares the flags shared by the actions that write files.
+func (s *State) writeFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&s.dryRun, "dry-run", false, "print the changes as a diff instead of writing them")
+	fs.BoolVar(&s.backup, "backup", false, "keep a copy of each changed file with a "+BackupSuffix+" suffix, timestamped if one exists")
+}
+
+// writeFile replaces the file, relative to the working directory, with the data. With -dry-run the changes are
+// printed as a unified diff instead, and with -backup the previous contents are kept. The mode of an existing file is
+// kept, otherwise new files get mode.
+func (s *State) writeFile(name string, data []byte, mode os.FileMode) error {
+	path := s.Path(name)
+
+	old, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	if s.dryRun {
+		from := diffName("a/", name)
+		if old == nil {
+			from = "/dev/null"
+		}
+		_, err := fmt.Fprint(s.Stdout, UnifiedDiff(from, diffName("b/", name), string(old), string(data), 3))
+		return err
+	}
+
+	// e.g. the directory of the global excludes file
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	if st, err := os.Stat(path); err == nil {
+		mode = st.Mode().Perm()
+	}
+
+	if s.backup && old != nil {
+		backup, err := backupName(path, time.Now())
+		if err != nil {
+			return err
+		}
+		if err := writeFileAtomic(backup, old, mode); err != nil {
+			return err
+		}
+		s.Logger().Infof("backed up %s to %s", name, filepath.Base(backup))
+	}
+
+	return writeFileAtomic(path, data, mode)
+}
+
+// backupName returns the file name with BackupSuffix, or if that exists, with the time and BackupSuffix so earlier
+// backups are kept. Backups made within the same second are numbered.
+func backupName(path string, now time.Time) (string, error) {
+	stamp := path + "." + now.Format("20060102T150405")
+	for n := 0; ; n++ {
+		var name string
+		switch n {
+		case 0:
+			name = path + BackupSuffix
+		case 1:
+			name = stamp + BackupSuffix
+		default:
+			name = fmt.Sprintf("%s-%d%s", stamp, n, BackupSuffix)
+		}
+
+		_, err := os.Lstat(name)
+		if os.IsNotExist(err) {
+			return name, nil
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+}
+
+// maxSymlinks is the number of symlinks followed before giving up, as on Linux.
+const maxSymlinks = 40
+
+// resolveSymlinks follows the symlinks starting at path to the file they name, which may not exist yet.
+func resolveSymlinks(path string) (string, error) {
+	for n := 0; n < maxSymlinks; n++ {
+		st, err := os.Lstat(path)
+		if os.IsNotExist(err) || (err == nil && st.Mode()&os.ModeSymlink == 0) {
+			return path, nil
+		}
+		if err != nil {
+			return "", err
+		}
+
+		target, err := os.Readlink(path)
+		if err != nil {
+			return "", err
+		}
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(path), target)
+		}
+		path = target
+	}
+
+	return "", fmt.Errorf("%s: too many levels of symbolic links", path)
+}
+
+// writeFileAtomic writes the data to a temporary file in the same directory and renames it over the file, so the file
+// is never left partially written. A symlink, even a dangling one, is resolved first so the file it points to is
+// written, not the link.
+func writeFileAtomic(path string, data []byte, mode os.FileMode) (err error) {
+	if path, err = resolveSymlinks(path); err != nil {
+		return err
+	}
+
+	fp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			fp.Close()
+			os.Remove(fp.Name())
+		}
+	}()
+
+	if _, err = fp.Write(data); err != nil {
+		return err
+	}
+	if err = fp.Chmod(mode); err != nil {
+		return err
+	}
+	if err = fp.Sync(); err != nil {
+		return err
+	}
+	if err = fp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(fp.Name(), path)
+}
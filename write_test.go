@@ -0,0 +1,214 @@
+package state
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestState_writeFile(t *testing.T) {
+	cases := []struct {
+		name    string
+		args    []string
+		exists  bool
+		stdout  string
+		content string
+		mode    os.FileMode
+		backups []string
+	}{
+		{"new", []string{"add"}, false, "", "new\n", 0644, nil},
+		{"replace", []string{"add"}, true, "", "new\n", 0600, nil},
+		{"backup", []string{"add", "-backup"}, true, "", "new\n", 0600, []string{".gitignore.bak"}},
+		{"backup without file", []string{"add", "-backup"}, false, "", "new\n", 0644, nil},
+		{
+			"dry run",
+			[]string{"add", "-dry-run"},
+			true,
+			"--- a/.gitignore\n+++ b/.gitignore\n@@ -1 +1 @@\n-old\n+new\n",
+			"old\n",
+			0600,
+			nil,
+		},
+		{"dry run without file", []string{"add", "-dry-run"}, false, "--- /dev/null\n+++ b/.gitignore\n@@ -0,0 +1 @@\n+new\n", "", 0, nil},
+	}
+
+	t.Parallel()
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			dir, cleanup := tempDir(t)
+			defer cleanup()
+
+			path := filepath.Join(dir, ".gitignore")
+			if tt.exists {
+				writeFile(t, path, "old\n")
+				require.NoError(t, os.Chmod(path, 0600))
+			}
+
+			s := newState(nil, "valid", append([]string{"-C", dir}, tt.args...)...)
+			defer s.Logger().ShutdownLoggers()
+			require.NoError(t, s.ParseArguments())
+			require.NoError(t, s.writeFile(".gitignore", []byte("new\n"), 0644))
+			assert.Equal(t, tt.stdout, s.Stdout.(*bytes.Buffer).String())
+
+			buf, err := ioutil.ReadFile(path)
+			if tt.content == "" {
+				assert.True(t, os.IsNotExist(err))
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.content, string(buf))
+				st, err := os.Stat(path)
+				require.NoError(t, err)
+				assert.Equal(t, tt.mode, st.Mode().Perm())
+			}
+
+			// only the file and its backups are left behind
+			var names []string
+			entries, err := ioutil.ReadDir(dir)
+			require.NoError(t, err)
+			for _, e := range entries {
+				if e.Name() != ".gitignore" {
+					names = append(names, e.Name())
+				}
+			}
+			assert.Equal(t, tt.backups, names)
+			for _, name := range tt.backups {
+				buf, err := ioutil.ReadFile(filepath.Join(dir, name))
+				require.NoError(t, err)
+				assert.Equal(t, "old\n", string(buf))
+			}
+		})
+	}
+}
+
+func TestState_writeFile_Symlink(t *testing.T) {
+	t.Parallel()
+
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	// dotfile managers link the file from a repository of their own
+	target := filepath.Join(dir, "dotfiles", "gitignore")
+	writeFile(t, target, "old\n")
+	require.NoError(t, os.Chmod(target, 0600))
+	link := filepath.Join(dir, "project", ".gitignore")
+	require.NoError(t, os.MkdirAll(filepath.Dir(link), 0755))
+	require.NoError(t, os.Symlink(filepath.Join("..", "dotfiles", "gitignore"), link))
+
+	s := newState(nil, "valid", "-C", filepath.Dir(link), "add")
+	defer s.Logger().ShutdownLoggers()
+	require.NoError(t, s.ParseArguments())
+	require.NoError(t, s.writeFile(".gitignore", []byte("new\n"), 0644))
+
+	st, err := os.Lstat(link)
+	require.NoError(t, err)
+	assert.Equal(t, os.ModeSymlink, st.Mode()&os.ModeSymlink)
+
+	buf, err := ioutil.ReadFile(target)
+	require.NoError(t, err)
+	assert.Equal(t, "new\n", string(buf))
+
+	st, err = os.Stat(target)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), st.Mode().Perm())
+}
+
+func TestState_writeFile_DanglingSymlink(t *testing.T) {
+	t.Parallel()
+
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	// the linked file hasn't been created yet
+	target := filepath.Join(dir, "dotfiles", "gitignore")
+	require.NoError(t, os.MkdirAll(filepath.Dir(target), 0755))
+	link := filepath.Join(dir, "project", ".gitignore")
+	require.NoError(t, os.MkdirAll(filepath.Dir(link), 0755))
+	require.NoError(t, os.Symlink(filepath.Join("..", "dotfiles", "gitignore"), link))
+
+	s := newState(nil, "valid", "-C", filepath.Dir(link), "add")
+	defer s.Logger().ShutdownLoggers()
+	require.NoError(t, s.ParseArguments())
+	require.NoError(t, s.writeFile(".gitignore", []byte("new\n"), 0644))
+
+	st, err := os.Lstat(link)
+	require.NoError(t, err)
+	assert.Equal(t, os.ModeSymlink, st.Mode()&os.ModeSymlink)
+
+	buf, err := ioutil.ReadFile(target)
+	require.NoError(t, err)
+	assert.Equal(t, "new\n", string(buf))
+}
+
+func TestResolveSymlinks_Loop(t *testing.T) {
+	t.Parallel()
+
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	link := filepath.Join(dir, ".gitignore")
+	require.NoError(t, os.Symlink(".gitignore", link))
+
+	_, err := resolveSymlinks(link)
+	assert.EqualError(t, err, link+": too many levels of symbolic links")
+}
+
+func TestBackupName(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	path := filepath.Join(dir, ".gitignore")
+	now := time.Date(2019, 3, 24, 21, 26, 58, 0, time.UTC)
+
+	name, err := backupName(path, now)
+	require.NoError(t, err)
+	assert.Equal(t, path+".bak", name)
+
+	writeFile(t, name, "")
+	name, err = backupName(path, now)
+	require.NoError(t, err)
+	assert.Equal(t, path+".20190324T212658.bak", name)
+
+	// a second backup within the same second
+	writeFile(t, name, "")
+	name, err = backupName(path, now)
+	require.NoError(t, err)
+	assert.Equal(t, path+".20190324T212658-2.bak", name)
+}
+
+func TestUpdateCommand_DryRun(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	path := filepath.Join(dir, ".gitignore")
+	writeFile(t, path, staleVSCode)
+
+	lock := "{\"version\": 1, \"repo\": \"github/gitignore\", \"ref\": \"master\", \"commit\": \"0000\"}\n"
+	writeFile(t, filepath.Join(dir, LockFile), lock)
+
+	s := newState(nil, "valid", "-C", dir, "update", "-dry-run")
+	require.NoError(t, s.ParseArguments())
+
+	cmd, err := s.Command()
+	require.NoError(t, err)
+	assert.Equal(t, ExitStatus(0), cmd.Run())
+	require.NoError(t, s.Logger().ShutdownLoggers())
+
+	stdout := s.Stdout.(*bytes.Buffer).String()
+	assert.Contains(t, stdout, "--- a/.gitignore\n+++ b/.gitignore\n")
+	assert.Contains(t, stdout, "--- a/.gitignore.lock\n+++ b/.gitignore.lock\n")
+
+	buf, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, staleVSCode, string(buf))
+
+	buf, err = ioutil.ReadFile(filepath.Join(dir, LockFile))
+	require.NoError(t, err)
+	assert.Equal(t, lock, string(buf))
+}